@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/hui1601/Quicky/lib/daemon"
+)
+
+func runDaemon(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	configPath := fs.String("config", "", "config file (default ~/.config/quicky/daemon.json)")
+	socket := fs.String("socket", "", "socket path, overrides the config")
+	useDBus := fs.Bool("dbus", false, "also publish the headsets on the D-Bus session bus")
+	useMPRIS := fs.Bool("mpris", false, "bridge music gestures and on-device players to MPRIS2")
+	httpAddr := fs.String("http", "", "also serve the REST API on this address, e.g. 127.0.0.1:8370")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configPath == "" {
+		path, err := daemon.DefaultConfigPath()
+		if err != nil {
+			return err
+		}
+		*configPath = path
+	}
+	cfg, err := daemon.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *socket != "" {
+		cfg.Socket = *socket
+	}
+	if *useDBus {
+		cfg.DBus = true
+	}
+	if *useMPRIS {
+		cfg.MPRIS = true
+	}
+	if *httpAddr != "" {
+		cfg.HTTP = *httpAddr
+	}
+
+	m, err := daemon.New(cfg)
+	if err != nil {
+		return err
+	}
+	m.Logf = func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+	ln, err := daemon.Listen(cfg.Socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(cfg.Socket)
+	// Stop the headsets and bridges too if Serve fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fmt.Fprintf(os.Stderr, "Serving %d headsets on %s\n", len(cfg.Headsets), cfg.Socket)
+
+	if cfg.DBus {
+		conn, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return fmt.Errorf("session bus: %w", err)
+		}
+		defer conn.Close()
+		go func() {
+			if err := m.ServeDBus(ctx, conn); err != nil {
+				fmt.Fprintf(os.Stderr, "quicky daemon: dbus: %v\n", err)
+			}
+		}()
+	}
+
+	if cfg.MPRIS {
+		go func() {
+			if err := m.ServeMPRIS(ctx, dbus.ConnectSessionBus); err != nil {
+				fmt.Fprintf(os.Stderr, "quicky daemon: mpris: %v\n", err)
+			}
+		}()
+	}
+
+	if cfg.HTTP != "" {
+		srv := &http.Server{Addr: cfg.HTTP, Handler: m.Handler()}
+		go func() {
+			<-ctx.Done()
+			srv.Close()
+		}()
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "quicky daemon: http: %v\n", err)
+			}
+		}()
+	}
+
+	// Wait for Run so the headsets are disconnected before exiting.
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		m.Run(ctx)
+	}()
+	err = m.Serve(ctx, ln)
+	cancel()
+	<-runDone
+	return err
+}
+
+func runRPC(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rpc", flag.ContinueOnError)
+	socket := fs.String("socket", daemon.DefaultSocketPath(), "daemon socket path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return errors.New("usage: rpc [-socket PATH] METHOD [PARAMS_JSON]")
+	}
+	var params json.RawMessage
+	if fs.NArg() == 2 {
+		params = json.RawMessage(fs.Arg(1))
+		if !json.Valid(params) {
+			return errors.New("params are not valid JSON")
+		}
+	}
+
+	client, err := daemon.Dial(*socket)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	go func() {
+		<-ctx.Done()
+		client.Close()
+	}()
+
+	var result json.RawMessage
+	if err := client.Call(fs.Arg(0), params, &result); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if fs.Arg(0) != "subscribe-events" {
+		return enc.Encode(result)
+	}
+	for {
+		ev, err := client.NextEvent()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+}
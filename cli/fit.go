@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+func runFit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fit", flag.ContinueOnError)
+	mac := fs.String("mac", "", "device address")
+	timeout := fs.Duration("timeout", 30*time.Second, "give up after this long")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *mac == "" {
+		return errors.New("-mac is required")
+	}
+
+	client, err := connect(ctx, *mac)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	fmt.Println("Put both earbuds in and stay quiet while the test tone plays.")
+
+	testCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	report, err := client.RunEarTipFitTestWithProgress(testCtx, func(res quicky.EarTipFitResult) {
+		if res.Status != quicky.EarTipFitStatusDone {
+			fmt.Println("  testing...")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Left:  score %d\n", report.Left)
+	fmt.Printf("Right: score %d\n", report.Right)
+	fmt.Println("The score scale is undocumented, so no fit verdict is given.")
+	return nil
+}
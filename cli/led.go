@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"time"
+
+	"github.com/hui1601/Quicky/lib/led"
+)
+
+func runLED(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("led", flag.ContinueOnError)
+	mac := fs.String("mac", "", "device address")
+	effect := fs.Uint("effect", 0, "effect index; the effects differ between models")
+	colors := fs.String("colors", "", "comma separated hex colors, e.g. ff0000,00ff00")
+	imagePath := fs.String("image", "", "take the palette from the dominant colors of an image")
+	count := fs.Int("count", 4, "number of colors taken from -image")
+	speed := fs.Int("speed", 50, "animation speed in percent")
+	brightness := fs.Int("brightness", 100, "brightness in percent")
+	read := fs.Bool("read", false, "print the current effect instead of setting one")
+	dryRun := fs.Bool("n", false, "print the palette without connecting")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *effect > 0xff {
+		return fmt.Errorf("effect index must be 0-255, got %d", *effect)
+	}
+	cfg := led.Config{Effect: led.Effect(*effect), Speed: *speed, Brightness: *brightness}
+	if !*read {
+		var err error
+		cfg.Colors, err = ledPalette(*colors, *imagePath, *count)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+	}
+	if *dryRun {
+		fmt.Println(cfg)
+		return nil
+	}
+	if *mac == "" {
+		return errors.New("-mac is required")
+	}
+
+	client, err := connect(ctx, *mac)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	if *read {
+		readCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		current, err := client.ReadLEDEffect(readCtx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(current)
+		return nil
+	}
+	return client.SetLEDConfig(cfg)
+}
+
+func ledPalette(colors, imagePath string, count int) (led.Palette, error) {
+	switch {
+	case colors != "":
+		return led.ParseHex(colors)
+	case imagePath != "":
+		f, err := os.Open(imagePath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			return nil, err
+		}
+		return led.FromImage(img, count), nil
+	}
+	return led.Palette{{R: 0xff, G: 0xff, B: 0xff, A: 0xff}}, nil
+}
@@ -1,5 +1,79 @@
 package main
 
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+type cliCommand struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = []cliCommand{
+	{name: "fit", usage: "fit -mac ADDR [-timeout 30s]  run a guided ear tip fit test", run: runFit},
+	{name: "led", usage: "led -mac ADDR [-effect N] [-colors HEX,..|-image FILE [-count N]] [-speed %] [-brightness %] [-read] [-n]  set or read the LED effect", run: runLED},
+	{name: "models", usage: "models [-search TEXT] [-category NAME] [-anc] [-min-bands N] [-keys] [-setting NAME] [-json]  list known models", run: runModels},
+	{name: "settings", usage: "settings -mac ADDR [NAME VALUE]  list the product's settings with their values, or set one", run: runSettings},
+	{name: "daemon", usage: "daemon [-config FILE] [-socket PATH] [-dbus] [-mpris] [-http ADDR]  keep configured headsets connected and serve JSON-RPC on a Unix socket", run: runDaemon},
+	{name: "rpc", usage: "rpc [-socket PATH] METHOD [PARAMS_JSON]  call a running daemon, e.g. rpc get-state '{\"device\":\"buds\"}'", run: runRPC},
+	{name: "productdb", usage: "productdb update [-base-url URL] [-o FILE] [-skip-panels] [-delay 300ms] [-q]  refresh the product database", run: runProductDB},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: quicky <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", cmd.usage)
+	}
+}
+
+func connect(ctx context.Context, mac string) (*quicky.Client, error) {
+	client, err := quicky.New(mac)
+	if err != nil {
+		return nil, err
+	}
+	// Connect also resolves the control MAC and, from the advertised vendor
+	// ID, the product that the settings commands need.
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := client.Connect(connectCtx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := quicky.LoadUserProductOverlays(); err != nil {
+		fmt.Fprintf(os.Stderr, "quicky: product overlays: %v\n", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+		if err := cmd.run(ctx, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "quicky %s: %v\n", cmd.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+	usage()
+	os.Exit(2)
 }
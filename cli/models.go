@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+func runModels(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("models", flag.ContinueOnError)
+	search := fs.String("search", "", "fuzzy match on title, subtitle and aliases")
+	category := fs.String("category", "", "earphones, watches, accessory or speaker")
+	anc := fs.Bool("anc", false, "only models with noise cancellation")
+	minBands := fs.Int("min-bands", 0, "only models with at least this many EQ bands")
+	keys := fs.Bool("keys", false, "only models with remappable touch controls")
+	setting := fs.String("setting", "", "only models with this settings item (name or type)")
+	asJSON := fs.Bool("json", false, "print full entries as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	models := quicky.Products()
+	if *search != "" {
+		models = quicky.SearchProducts(*search)
+	}
+	var inCategory map[uint16]bool
+	if *category != "" {
+		inCategory = make(map[uint16]bool)
+		for _, p := range quicky.ProductsByCategory(*category) {
+			inCategory[p.VendorId] = true
+		}
+	}
+	var out []quicky.Product
+	for _, p := range models {
+		switch {
+		case inCategory != nil && !inCategory[p.VendorId],
+			*anc && !p.HasANC(),
+			p.EQBands() < *minBands,
+			*keys && !p.HasKeyFunction(),
+			*setting != "" && !p.HasSetting(*setting):
+			continue
+		}
+		out = append(out, p)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VENDOR\tTITLE\tSUBTITLE\tCATEGORY\tFEATURES")
+	for _, p := range out {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", p.VendorId, p.Title, p.SubTitle, p.Category, featureSummary(p))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d models\n", len(out))
+	return nil
+}
+
+func featureSummary(p quicky.Product) string {
+	var f []string
+	if p.HasANC() {
+		f = append(f, fmt.Sprintf("anc(%d)", len(p.Features.ANC.Modes)))
+	}
+	if n := p.EQBands(); n > 0 {
+		f = append(f, fmt.Sprintf("eq(%d)", n))
+	}
+	if p.HasKeyFunction() {
+		f = append(f, "keys")
+	}
+	if p.Features.ChannelBalance {
+		f = append(f, "balance")
+	}
+	if p.Features.FindEarphone {
+		f = append(f, "find")
+	}
+	if p.Features.DeviceName {
+		f = append(f, "rename")
+	}
+	if p.Features.AutoOffTimer != nil {
+		f = append(f, "auto-off")
+	}
+	if len(p.Annotations) > 0 {
+		f = append(f, "annotated")
+	}
+	return strings.Join(f, " ")
+}
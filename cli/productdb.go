@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/productdb"
+)
+
+func runProductDB(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "update" {
+		return errors.New("usage: productdb update [flags]")
+	}
+	fs := flag.NewFlagSet("productdb update", flag.ContinueOnError)
+	baseURL := fs.String("base-url", productdb.DefaultBaseURL, "product API base URL")
+	out := fs.String("o", "internal/product/products.json", "output file")
+	skipPanels := fs.Bool("skip-panels", false, "only fetch the product list, without control panels")
+	delay := fs.Duration("delay", 300*time.Millisecond, "pause between control panel requests")
+	quiet := fs.Bool("q", false, "do not print progress")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	u := &productdb.Updater{
+		BaseURL:    *baseURL,
+		Client:     &http.Client{Timeout: time.Minute},
+		PanelDelay: *delay,
+		SkipPanels: *skipPanels,
+	}
+	if !*quiet {
+		u.Logf = func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		}
+	}
+	products, err := u.Update(ctx)
+	if err != nil {
+		return err
+	}
+	if err := productdb.WriteFile(*out, products); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %d products to %s\n", len(products), *out)
+	return nil
+}
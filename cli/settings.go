@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+func runSettings(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("settings", flag.ContinueOnError)
+	mac := fs.String("mac", "", "device address")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *mac == "" {
+		return errors.New("-mac is required")
+	}
+	if fs.NArg() == 1 || fs.NArg() > 2 {
+		return errors.New("usage: settings -mac ADDR [NAME VALUE]")
+	}
+
+	client, err := connect(ctx, *mac)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	if fs.NArg() == 2 {
+		return client.SetSetting(ctx, fs.Arg(0), fs.Arg(1))
+	}
+
+	settings, err := client.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tOPCODE\tVALUE\tVALUES")
+	for _, s := range settings {
+		opcode := "-"
+		if s.Opcode != 0 {
+			opcode = fmt.Sprintf("0x%02X", s.Opcode)
+		}
+		value := s.Value()
+		if !s.Known() {
+			value = "-"
+		}
+		var values string
+		switch s.Kind {
+		case quicky.SettingToggle:
+			values = "on, off"
+		case quicky.SettingText:
+			values = "text"
+		case quicky.SettingSlider:
+			values = "0-255"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Kind, opcode, value, values)
+	}
+	return w.Flush()
+}
@@ -2,9 +2,13 @@ module github.com/hui1601/Quicky
 
 go 1.23.1
 
+require (
+	github.com/godbus/dbus/v5 v5.1.0
+	tinygo.org/x/bluetooth v0.10.0
+)
+
 require (
 	github.com/go-ole/go-ole v1.3.0 // indirect
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/saltosystems/winrt-go v0.0.0-20240510082706-db61b37f5877 // indirect
 	github.com/sirupsen/logrus v1.9.3 // indirect
 	github.com/soypat/cyw43439 v0.0.0-20240627234239-a62ee4027d66 // indirect
@@ -13,5 +17,4 @@ require (
 	github.com/tinygo-org/pio v0.0.0-20240901140349-27cbe9d986eb // indirect
 	golang.org/x/exp v0.0.0-20241009180824-f66d83c29e7c // indirect
 	golang.org/x/sys v0.26.0 // indirect
-	tinygo.org/x/bluetooth v0.10.0 // indirect
 )
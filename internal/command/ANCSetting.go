@@ -1,5 +1,138 @@
 package command
 
+import "fmt"
+
 func NewANCSettingCommand(mode, subScene, noiseValue byte) *Command {
 	return NewCommand(0x17, []byte{mode, subScene, noiseValue})
 }
+
+// ANCEnvironment is the mode byte of an ANC scene (cmd 0x17). The modes are
+// those the control panels in the product database use.
+type ANCEnvironment byte
+
+const (
+	ANCEnvironmentOff          ANCEnvironment = 0x00
+	ANCEnvironmentANC          ANCEnvironment = 0x01
+	ANCEnvironmentNormal       ANCEnvironment = 0x02
+	ANCEnvironmentTransparency ANCEnvironment = 0x03
+)
+
+// ancRange bounds the sub-scene and noise value of an environment.
+type ancRange struct {
+	maxSubScene byte
+	maxNoise    byte
+}
+
+// ancRanges holds the ranges of the control panels: noise canceling spans
+// 0x010000-0x01ffff with sub-scenes 1-5 (noisy, commuting, indoor,
+// anti-wind, adaptive), normal is 0x020000 and transparency 0x030000 or
+// 0x030100-0x030106. Transparency sub-scene 2 is what the app decodes
+// 0x030100 to.
+var ancRanges = map[ANCEnvironment]ancRange{
+	ANCEnvironmentOff:          {0, 0},
+	ANCEnvironmentANC:          {5, 0xff},
+	ANCEnvironmentNormal:       {0, 0},
+	ANCEnvironmentTransparency: {2, 6},
+}
+
+func (e ANCEnvironment) String() string {
+	switch e {
+	case ANCEnvironmentOff:
+		return "off"
+	case ANCEnvironmentANC:
+		return "anc"
+	case ANCEnvironmentNormal:
+		return "normal"
+	case ANCEnvironmentTransparency:
+		return "transparency"
+	}
+	return fmt.Sprintf("mode(0x%02x)", byte(e))
+}
+
+// ANCScene is a combined noise mode as sent with cmd 0x17.
+// The app packs it into a single integer: (mode << 16) | (subScene << 8) | noiseValue.
+type ANCScene struct {
+	Mode       byte
+	SubScene   byte
+	NoiseValue byte
+}
+
+// ANCSceneOff is the "off" scene.
+var ANCSceneOff = ANCScene{}
+
+// NewANCScene returns the scene of an environment, checking the sub-scene
+// and noise value against the ranges of the control panels.
+func NewANCScene(env ANCEnvironment, subScene, noiseValue byte) (ANCScene, error) {
+	r, ok := ancRanges[env]
+	if !ok {
+		return ANCScene{}, fmt.Errorf("anc scene: unknown environment 0x%02x", byte(env))
+	}
+	if subScene > r.maxSubScene {
+		return ANCScene{}, fmt.Errorf("anc scene: %s sub-scene must be 0-%d, got %d", env, r.maxSubScene, subScene)
+	}
+	if noiseValue > r.maxNoise {
+		return ANCScene{}, fmt.Errorf("anc scene: %s noise value must be 0-%d, got %d", env, r.maxNoise, noiseValue)
+	}
+	return ANCScene{Mode: byte(env), SubScene: subScene, NoiseValue: noiseValue}, nil
+}
+
+func splitANCScene(packed uint32) ANCScene {
+	return ANCScene{
+		Mode:       byte(packed >> 16),
+		SubScene:   byte(packed >> 8),
+		NoiseValue: byte(packed),
+	}
+}
+
+// UnpackANCScene splits a packed scene value reported by the device, applying
+// the same remap as the app (mode=3, subScene=1, noiseValue=0 becomes
+// subScene=2).
+func UnpackANCScene(packed uint32) ANCScene {
+	s := splitANCScene(packed)
+	if s.Mode == 0x03 && s.SubScene == 0x01 && s.NoiseValue == 0x00 {
+		s.SubScene = 0x02
+	}
+	return s
+}
+
+// Packed returns the scene in the app's packed integer format.
+func (s ANCScene) Packed() uint32 {
+	return uint32(s.Mode)<<16 | uint32(s.SubScene)<<8 | uint32(s.NoiseValue)
+}
+
+// Environment returns the mode byte as an ANCEnvironment.
+func (s ANCScene) Environment() ANCEnvironment {
+	return ANCEnvironment(s.Mode)
+}
+
+// Known reports whether the scene lies within the ranges of the control panels.
+func (s ANCScene) Known() bool {
+	r, ok := ancRanges[s.Environment()]
+	return ok && s.SubScene <= r.maxSubScene && s.NoiseValue <= r.maxNoise
+}
+
+// String returns "off", the environment followed by the sub-scene and noise
+// value where they are set, e.g. "normal", "anc/2" or "transparency/1/4", or
+// the packed value in hex for unknown scenes.
+func (s ANCScene) String() string {
+	if !s.Known() {
+		return fmt.Sprintf("0x%06x", s.Packed())
+	}
+	out := s.Environment().String()
+	if s.SubScene != 0 || s.NoiseValue != 0 {
+		out += fmt.Sprintf("/%d", s.SubScene)
+	}
+	if s.NoiseValue != 0 {
+		out += fmt.Sprintf("/%d", s.NoiseValue)
+	}
+	return out
+}
+
+// NewANCSceneCommand encodes a scene. Values that fit in a single byte use the
+// simple noise cancel mode command (0x0C); everything else uses 0x17.
+func NewANCSceneCommand(s ANCScene) *Command {
+	if packed := s.Packed(); packed <= 0xff {
+		return NewCommand(0x0c, []byte{byte(packed)})
+	}
+	return NewANCSettingCommand(s.Mode, s.SubScene, s.NoiseValue)
+}
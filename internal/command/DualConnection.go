@@ -0,0 +1,6 @@
+package command
+
+// NewDualConnectionCommand enables or disables multipoint (dual device) connection.
+func NewDualConnectionCommand(enable bool) *Command {
+	return NewCommand(0x24, []byte{onOff(enable)})
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// enumTable describes a single-byte opcode argument: its known names and the
+// range of values the device accepts.
+type enumTable struct {
+	kind  string
+	names map[byte]string
+	min   byte
+	max   byte
+}
+
+func (t enumTable) name(v byte) string {
+	if n, ok := t.names[v]; ok {
+		return n
+	}
+	return fmt.Sprintf("%s(0x%02x)", t.kind, v)
+}
+
+func (t enumTable) validate(v byte) error {
+	if v < t.min || v > t.max {
+		return fmt.Errorf("%s: value 0x%02x out of range 0x%02x-0x%02x", t.kind, v, t.min, t.max)
+	}
+	return nil
+}
+
+// parse accepts a known name (case-insensitive) or a decimal/hex number.
+func (t enumTable) parse(s string) (byte, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for v, n := range t.names {
+		if n == s {
+			return v, nil
+		}
+	}
+	n, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%s: unknown value %q", t.kind, s)
+	}
+	if err := t.validate(byte(n)); err != nil {
+		return 0, err
+	}
+	return byte(n), nil
+}
+
+// onOff encodes the common 0x01 = on, 0x02 = off convention.
+func onOff(on bool) byte {
+	if on {
+		return 0x01
+	}
+	return 0x02
+}
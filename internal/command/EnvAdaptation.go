@@ -1,5 +1,5 @@
 package command
 
-func NewEnvAdaptationCommand(state byte) *Command {
-	return NewCommand(0x32, []byte{state})
+func NewEnvAdaptationCommand(enable bool) *Command {
+	return NewCommand(0x32, []byte{onOff(enable)})
 }
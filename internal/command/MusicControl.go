@@ -3,6 +3,36 @@ package command
 // MusicControlAction is not used in the app, but command code is present in the app.
 type MusicControlAction int16
 
+const (
+	MusicPlay     MusicControlAction = 0x01
+	MusicPause    MusicControlAction = 0x02
+	MusicPrevious MusicControlAction = 0x03
+	MusicNext     MusicControlAction = 0x04
+)
+
+var musicControlActions = enumTable{
+	kind:  "music control",
+	names: map[byte]string{0x01: "play", 0x02: "pause", 0x03: "previous", 0x04: "next"},
+	min:   0x01,
+	max:   0x04,
+}
+
+func (a MusicControlAction) String() string {
+	return musicControlActions.name(byte(a))
+}
+
+func (a MusicControlAction) Validate() error {
+	if a < 0 || a > 0xff {
+		return musicControlActions.validate(0)
+	}
+	return musicControlActions.validate(byte(a))
+}
+
+func ParseMusicControlAction(s string) (MusicControlAction, error) {
+	v, err := musicControlActions.parse(s)
+	return MusicControlAction(v), err
+}
+
 func NewMusicControlCommand(action MusicControlAction) *Command {
 	return NewCommand(0x04, []byte{byte(action)})
 }
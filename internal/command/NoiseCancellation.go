@@ -1,5 +1,7 @@
 package command
 
+import "fmt"
+
 type NoiseCancelMode byte
 
 const (
@@ -12,3 +14,17 @@ const (
 func NewNoiseCancelModeCommand(mode NoiseCancelMode) *Command {
 	return NewCommand(0x0c, []byte{byte(mode)})
 }
+
+func (m NoiseCancelMode) String() string {
+	switch m {
+	case NoiseCancelOff:
+		return "off"
+	case NoiseCancelANC:
+		return "anc"
+	case NoiseCancelOutdoor:
+		return "outdoor"
+	case NoiseCancelTransparency:
+		return "transparency"
+	}
+	return fmt.Sprintf("mode(0x%02x)", byte(m))
+}
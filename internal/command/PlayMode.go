@@ -1,5 +1,35 @@
 package command
 
-func NewPlayModeCommand(mode byte) *Command {
-	return NewCommand(0x37, []byte{mode})
+// PlayMode is the playback order of the on-device music library (cmd 0x37
+// and the last byte of 0x3A). The values follow the 0x01/0x02 convention of
+// the other two-state commands.
+type PlayMode byte
+
+const (
+	PlayModeShuffle   PlayMode = 0x01
+	PlayModeRepeatOne PlayMode = 0x02
+)
+
+var playModes = enumTable{
+	kind:  "play mode",
+	names: map[byte]string{0x01: "shuffle", 0x02: "repeat-one"},
+	min:   0x01,
+	max:   0x02,
+}
+
+func (m PlayMode) String() string {
+	return playModes.name(byte(m))
+}
+
+func (m PlayMode) Validate() error {
+	return playModes.validate(byte(m))
+}
+
+func ParsePlayMode(s string) (PlayMode, error) {
+	v, err := playModes.parse(s)
+	return PlayMode(v), err
+}
+
+func NewPlayModeCommand(mode PlayMode) *Command {
+	return NewCommand(0x37, []byte{byte(mode)})
 }
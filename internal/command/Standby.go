@@ -1,5 +1,5 @@
 package command
 
-func NewStandbyCommand(state byte) *Command {
-	return NewCommand(0x1f, []byte{state})
+func NewStandbyCommand(enable bool) *Command {
+	return NewCommand(0x1f, []byte{onOff(enable)})
 }
@@ -2,7 +2,9 @@ package command
 
 import "time"
 
-func NewSyncTimeCommand(t time.Time) *Command {
+// NewSyncTimeData encodes t as [year%100, month, day, hour, minute, second, dayOfWeekBitmask].
+// The bitmask is 1 << (dow-1) with Sunday = 1, which is 1 << Weekday() in Go terms.
+func NewSyncTimeData(t time.Time) []byte {
 	year := byte(t.Year() % 100)
 	month := byte(t.Month())
 	day := byte(t.Day())
@@ -10,5 +12,9 @@ func NewSyncTimeCommand(t time.Time) *Command {
 	minute := byte(t.Minute())
 	second := byte(t.Second())
 	dow := byte(1 << (t.Weekday())) // Sunday=0 → bit 0
-	return NewCommand(0x3e, []byte{year, month, day, hour, minute, second, dow})
+	return []byte{year, month, day, hour, minute, second, dow}
+}
+
+func NewSyncTimeCommand(t time.Time) *Command {
+	return NewCommand(0x3e, NewSyncTimeData(t))
 }
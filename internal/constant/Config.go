@@ -10,12 +10,15 @@ func init() {
 	KeyFuncUUID, _ = bluetooth.ParseUUID("0000000d-0000-1000-8000-00805f9b34fb")
 	BatteryUUID, _ = bluetooth.ParseUUID("00000008-0000-1000-8000-00805f9b34fb")
 	VersionUUID, _ = bluetooth.ParseUUID("00000007-0000-1000-8000-00805f9b34fb")
+	TimeUUID, _ = bluetooth.ParseUUID("0000000c-0000-1000-8000-00805f9b34fb")
 	CCCDUUID, _ = bluetooth.ParseUUID("00002902-0000-1000-8000-00805f9b34fb")
 }
 
 const (
 	// QCYCompanyID is the BLE manufacturer data company ID for QCY devices.
 	QCYCompanyID uint16 = 0x521c
+	// QCYWatchCompanyID is the BLE manufacturer data company ID for QCY watches.
+	QCYWatchCompanyID uint16 = 0x05d6
 )
 
 var (
@@ -33,6 +36,8 @@ var (
 	BatteryUUID bluetooth.UUID
 	// VersionUUID is the version read characteristic UUID.
 	VersionUUID bluetooth.UUID
+	// TimeUUID is the V1 send-time characteristic UUID (no 0xFF framing, older models only).
+	TimeUUID bluetooth.UUID
 	// CCCDUUID is the Client Characteristic Configuration Descriptor UUID.
 	CCCDUUID bluetooth.UUID
 )
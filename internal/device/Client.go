@@ -22,6 +22,8 @@ type Client struct {
 	keyFuncChar bluetooth.DeviceCharacteristic
 	batteryChar bluetooth.DeviceCharacteristic
 	versionChar bluetooth.DeviceCharacteristic
+	timeChar    bluetooth.DeviceCharacteristic
+	hasTimeChar bool
 
 	events    chan response.Event
 	mu        sync.Mutex
@@ -121,6 +123,13 @@ func (c *Client) discoverCharacteristics() error {
 	c.batteryChar = chars[4]
 	c.versionChar = chars[5]
 
+	// The V1 time characteristic only exists on older models.
+	c.hasTimeChar = false
+	if timeChars, err := services[0].DiscoverCharacteristics([]bluetooth.UUID{constant.TimeUUID}); err == nil && len(timeChars) == 1 {
+		c.timeChar = timeChars[0]
+		c.hasTimeChar = true
+	}
+
 	return nil
 }
 
@@ -202,6 +211,28 @@ func (c *Client) WriteKeyFunction(data []byte) error {
 	return err
 }
 
+func (c *Client) HasTimeCharacteristic() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected && c.hasTimeChar
+}
+
+func (c *Client) WriteTime(data []byte) error {
+	c.mu.Lock()
+	if !c.connected {
+		c.mu.Unlock()
+		return errors.New("not connected")
+	}
+	if !c.hasTimeChar {
+		c.mu.Unlock()
+		return errors.New("time characteristic not available")
+	}
+	c.mu.Unlock()
+
+	_, err := c.timeChar.WriteWithoutResponse(data)
+	return err
+}
+
 func (c *Client) ReadBattery() (response.Battery, error) {
 	c.mu.Lock()
 	if !c.connected {
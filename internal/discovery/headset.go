@@ -0,0 +1,186 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/product"
+	"github.com/hui1601/Quicky/internal/utils"
+)
+
+// AddressRole tells which of the three MACs an advertising address is.
+type AddressRole int
+
+const (
+	RoleBud AddressRole = iota
+	RoleControl
+)
+
+func (r AddressRole) String() string {
+	if r == RoleControl {
+		return "control"
+	}
+	return "bud"
+}
+
+// HeadsetSource is one address a headset advertised from.
+type HeadsetSource struct {
+	Address  string
+	Role     AddressRole
+	RSSI     int16
+	LastSeen time.Time
+}
+
+// Headset merges the advertisements of both buds and the control address.
+type Headset struct {
+	ControlMAC string
+	// BudMACs are the earbud addresses seen so far: advertising addresses
+	// other than the control MAC plus the advertised OtherMAC.
+	BudMACs       []string
+	Name          string
+	VendorID      uint16
+	Left          BatteryLevel
+	Right         BatteryLevel
+	Case          BatteryLevel
+	Sources       map[string]HeadsetSource // keyed by advertising address
+	FirstSeen     time.Time
+	LastSeen      time.Time
+	Advertisement *utils.AdvertisementInfo
+}
+
+type BatteryLevel struct {
+	Level    byte
+	Charging bool
+}
+
+// Product looks up the product definition for the advertised vendor ID.
+func (h Headset) Product() (*product.Product, bool) {
+	return product.Lookup(h.VendorID)
+}
+
+// Has reports whether addr is the control MAC or one of the bud MACs.
+func (h Headset) Has(addr string) bool {
+	addr = strings.ToLower(addr)
+	if addr == h.ControlMAC {
+		return true
+	}
+	for _, b := range h.BudMACs {
+		if b == addr {
+			return true
+		}
+	}
+	_, ok := h.Sources[addr]
+	return ok
+}
+
+// BestRSSI returns the strongest RSSI among the sources seen within maxAge.
+func (h Headset) BestRSSI(now time.Time, maxAge time.Duration) (int16, bool) {
+	var best int16
+	found := false
+	for _, s := range h.Sources {
+		if now.Sub(s.LastSeen) > maxAge {
+			continue
+		}
+		if !found || s.RSSI > best {
+			best, found = s.RSSI, true
+		}
+	}
+	return best, found
+}
+
+// BudRSSI returns the RSSI of each bud address seen within maxAge. The
+// advertisement does not say which side is advertising (both buds report
+// both battery levels), so buds are told apart by address, not as left and
+// right.
+func (h Headset) BudRSSI(now time.Time, maxAge time.Duration) map[string]int16 {
+	rssi := make(map[string]int16)
+	for addr, s := range h.Sources {
+		if s.Role == RoleBud && now.Sub(s.LastSeen) <= maxAge {
+			rssi[addr] = s.RSSI
+		}
+	}
+	return rssi
+}
+
+func (h *Headset) clone() Headset {
+	c := *h
+	c.BudMACs = append([]string(nil), h.BudMACs...)
+	c.Sources = make(map[string]HeadsetSource, len(h.Sources))
+	for k, v := range h.Sources {
+		c.Sources[k] = v
+	}
+	return c
+}
+
+func (h *Headset) addBud(mac string) {
+	if mac == "" || mac == h.ControlMAC {
+		return
+	}
+	for _, b := range h.BudMACs {
+		if b == mac {
+			return
+		}
+	}
+	h.BudMACs = append(h.BudMACs, mac)
+}
+
+func (h *Headset) observe(res ScanResult, now time.Time) {
+	adv := res.Advertisement
+	addr := strings.ToLower(res.Address.String())
+	role := RoleBud
+	if addr == h.ControlMAC {
+		role = RoleControl
+	} else {
+		h.addBud(addr)
+	}
+	h.addBud(strings.ToLower(adv.OtherMAC))
+
+	h.Sources[addr] = HeadsetSource{Address: addr, Role: role, RSSI: res.RSSI, LastSeen: now}
+	if res.Name != "" {
+		h.Name = res.Name
+	}
+	h.VendorID = adv.VendorID
+	h.Left = BatteryLevel{Level: adv.LeftBattery, Charging: adv.IsLeftCharging}
+	h.Right = BatteryLevel{Level: adv.RightBattery, Charging: adv.IsRightCharging}
+	h.Case = BatteryLevel{Level: adv.BoxBattery, Charging: adv.IsBoxCharging}
+	h.LastSeen = now
+	h.Advertisement = adv
+}
+
+// zeroMAC is what the control MAC field holds before it is broadcast.
+const zeroMAC = "00:00:00:00:00:00"
+
+// headsetKey returns the control MAC an advertisement belongs to, or "" if
+// the advertisement does not carry one yet.
+func headsetKey(res ScanResult) string {
+	if res.Advertisement == nil || res.Advertisement.ControlMAC == zeroMAC {
+		return ""
+	}
+	return strings.ToLower(res.Advertisement.ControlMAC)
+}
+
+// FindHeadset scans until a headset advertising addr as its BLE, control or
+// other MAC is found, or ctx is done.
+func (s *Scanner) FindHeadset(ctx context.Context, addr string) (Headset, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	reg, err := s.ScanRegistry(ctx, ScanOptions{Dedupe: true})
+	if err != nil {
+		cancel()
+		return Headset{}, err
+	}
+	defer func() {
+		cancel()
+		<-reg.Done()
+	}()
+	for range reg.Events() {
+		if h, ok := reg.Headset(addr); ok {
+			return h, nil
+		}
+	}
+	if err := reg.Err(); err != nil {
+		return Headset{}, err
+	}
+	return Headset{}, fmt.Errorf("headset %s not found: %w", addr, ctx.Err())
+}
@@ -0,0 +1,378 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/product"
+	"github.com/hui1601/Quicky/internal/utils"
+	"tinygo.org/x/bluetooth"
+)
+
+const (
+	defaultLostAfter = 30 * time.Second
+	// rssiSmoothing is the weight of a new RSSI sample in the moving average.
+	rssiSmoothing = 0.3
+)
+
+type ScanOptions struct {
+	// VendorIDs limits results to these product vendor IDs. Empty accepts all.
+	VendorIDs []uint16
+	// NameContains limits results to devices whose local name contains this
+	// string (case-insensitive).
+	NameContains string
+	// MinRSSI drops advertisements weaker than this. Zero accepts all.
+	MinRSSI int16
+	// Duration stops the scan after this long. Zero scans until ctx is done.
+	Duration time.Duration
+	// Dedupe reports DeviceUpdated only when the advertised data changes
+	// instead of for every advertisement.
+	Dedupe bool
+	// LostAfter is how long a device may stay silent before DeviceLost.
+	// Defaults to 30 seconds.
+	LostAfter time.Duration
+	// Watches also reports QCY watches. Their devices have a nil
+	// Advertisement and are never grouped into headsets.
+	Watches bool
+}
+
+func (o ScanOptions) match(r ScanResult) bool {
+	if o.MinRSSI != 0 && r.RSSI < o.MinRSSI {
+		return false
+	}
+	if o.NameContains != "" && !strings.Contains(strings.ToLower(r.Name), strings.ToLower(o.NameContains)) {
+		return false
+	}
+	if len(o.VendorIDs) == 0 {
+		return true
+	}
+	vendorID, ok := r.VendorID()
+	if !ok {
+		return false
+	}
+	for _, id := range o.VendorIDs {
+		if vendorID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Device is a registry entry for one advertising address.
+type Device struct {
+	Address       bluetooth.Address
+	Name          string
+	FirstSeen     time.Time
+	LastSeen      time.Time
+	RSSI          int16   // last raw RSSI
+	SmoothedRSSI  float64 // exponential moving average of RSSI
+	Seen          int     // number of advertisements received
+	Advertisement *utils.AdvertisementInfo
+	Watch         *utils.WatchAdvertisement
+}
+
+// Product looks up the product definition for the advertised vendor ID.
+func (d Device) Product() (*product.Product, bool) {
+	vendorID, ok := ScanResult{Advertisement: d.Advertisement, Watch: d.Watch}.VendorID()
+	if !ok {
+		return nil, false
+	}
+	return product.Lookup(vendorID)
+}
+
+type RegistryEventType int
+
+const (
+	DeviceAppeared RegistryEventType = iota
+	DeviceUpdated
+	DeviceLost
+)
+
+func (t RegistryEventType) String() string {
+	switch t {
+	case DeviceAppeared:
+		return "appeared"
+	case DeviceUpdated:
+		return "updated"
+	case DeviceLost:
+		return "lost"
+	}
+	return "unknown"
+}
+
+type RegistryEvent struct {
+	Type   RegistryEventType
+	Device Device
+}
+
+// Registry is the live set of devices found by a scan.
+type Registry struct {
+	opts ScanOptions
+
+	mu       sync.Mutex
+	devices  map[string]*Device
+	headsets map[string]*Headset
+	events   chan RegistryEvent
+	done     chan struct{}
+	err      error
+}
+
+func newRegistry(opts ScanOptions) *Registry {
+	if opts.LostAfter <= 0 {
+		opts.LostAfter = defaultLostAfter
+	}
+	return &Registry{
+		opts:     opts,
+		devices:  make(map[string]*Device),
+		headsets: make(map[string]*Headset),
+		events:   make(chan RegistryEvent, 256),
+		done:     make(chan struct{}),
+	}
+}
+
+// Events returns appeared, updated and lost events. The channel is closed when
+// the scan ends. Events are dropped if the channel is full.
+func (r *Registry) Events() <-chan RegistryEvent {
+	return r.events
+}
+
+// Done is closed when the scan has ended.
+func (r *Registry) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns the error that ended the scan, if any. Valid after Done is closed.
+func (r *Registry) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Devices returns a snapshot of all devices currently in the registry.
+func (r *Registry) Devices() []Device {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Device, 0, len(r.devices))
+	for _, d := range r.devices {
+		out = append(out, *d)
+	}
+	return out
+}
+
+// Get returns the device advertising from addr.
+func (r *Registry) Get(addr string) (Device, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.devices[strings.ToLower(addr)]
+	if !ok {
+		return Device{}, false
+	}
+	return *d, true
+}
+
+// Headsets returns a snapshot of all headsets, with the advertisements of both
+// buds and the control address merged by control MAC.
+func (r *Registry) Headsets() []Headset {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Headset, 0, len(r.headsets))
+	for _, h := range r.headsets {
+		out = append(out, h.clone())
+	}
+	return out
+}
+
+// Headset returns the headset that addr (BLE, control or other MAC) belongs to.
+func (r *Registry) Headset(addr string) (Headset, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range r.headsets {
+		if h.Has(addr) {
+			return h.clone(), true
+		}
+	}
+	return Headset{}, false
+}
+
+func (r *Registry) observeHeadset(res ScanResult, now time.Time) {
+	key := headsetKey(res)
+	if key == "" {
+		return
+	}
+	h, ok := r.headsets[key]
+	if !ok {
+		h = &Headset{ControlMAC: key, Sources: make(map[string]HeadsetSource), FirstSeen: now}
+		r.headsets[key] = h
+	}
+	h.observe(res, now)
+}
+
+func (r *Registry) emit(ev RegistryEvent) {
+	select {
+	case r.events <- ev:
+	default:
+	}
+}
+
+func (r *Registry) observe(res ScanResult, now time.Time) {
+	if !r.opts.match(res) {
+		return
+	}
+	key := strings.ToLower(res.Address.String())
+
+	r.mu.Lock()
+	r.observeHeadset(res, now)
+	d, ok := r.devices[key]
+	if !ok {
+		d = &Device{
+			Address:       res.Address,
+			Name:          res.Name,
+			FirstSeen:     now,
+			LastSeen:      now,
+			RSSI:          res.RSSI,
+			SmoothedRSSI:  float64(res.RSSI),
+			Seen:          1,
+			Advertisement: res.Advertisement,
+			Watch:         res.Watch,
+		}
+		r.devices[key] = d
+		snapshot := *d
+		r.mu.Unlock()
+		r.emit(RegistryEvent{Type: DeviceAppeared, Device: snapshot})
+		return
+	}
+
+	changed := res.Name != d.Name || !sameAdvertisement(d.Advertisement, res.Advertisement) ||
+		!sameWatch(d.Watch, res.Watch)
+	d.LastSeen = now
+	d.RSSI = res.RSSI
+	d.SmoothedRSSI += rssiSmoothing * (float64(res.RSSI) - d.SmoothedRSSI)
+	d.Seen++
+	if res.Name != "" {
+		d.Name = res.Name
+	}
+	d.Advertisement = res.Advertisement
+	d.Watch = res.Watch
+	snapshot := *d
+	r.mu.Unlock()
+
+	if changed || !r.opts.Dedupe {
+		r.emit(RegistryEvent{Type: DeviceUpdated, Device: snapshot})
+	}
+}
+
+func sameAdvertisement(a, b *utils.AdvertisementInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameWatch(a, b *utils.WatchAdvertisement) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.VendorID == b.VendorID && bytes.Equal(a.Raw, b.Raw)
+}
+
+func (r *Registry) expire(now time.Time) {
+	r.mu.Lock()
+	var lost []Device
+	for key, d := range r.devices {
+		if now.Sub(d.LastSeen) > r.opts.LostAfter {
+			lost = append(lost, *d)
+			delete(r.devices, key)
+		}
+	}
+	for key, h := range r.headsets {
+		if now.Sub(h.LastSeen) > r.opts.LostAfter {
+			delete(r.headsets, key)
+		}
+	}
+	r.mu.Unlock()
+	for _, d := range lost {
+		r.emit(RegistryEvent{Type: DeviceLost, Device: d})
+	}
+}
+
+func (r *Registry) finish(err error) {
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+	close(r.events)
+	close(r.done)
+}
+
+// stopRetry is how often ScanRegistry retries stopping a scan that had not
+// started yet when ctx was done.
+const stopRetry = 50 * time.Millisecond
+
+// ScanRegistry enables the adapter, starts scanning in the background and
+// returns the live registry. The scan stops when ctx is done or opts.Duration elapses.
+func (s *Scanner) ScanRegistry(ctx context.Context, opts ScanOptions) (*Registry, error) {
+	if err := s.adapter.Enable(); err != nil {
+		return nil, err
+	}
+	reg := newRegistry(opts)
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		go func() {
+			<-reg.done
+			cancel()
+		}()
+	}
+
+	// ctx can be done before the scan has started, when StopScan still
+	// fails and no advertisement may arrive to retry it, so keep stopping
+	// until the scan has returned.
+	scanDone := make(chan struct{})
+	stopScan := context.AfterFunc(ctx, func() {
+		for s.StopScan() != nil {
+			select {
+			case <-scanDone:
+				return
+			case <-time.After(stopRetry):
+			}
+		}
+	})
+	results := make(chan ScanResult, 64)
+	scanErr := make(chan error, 1)
+	go func() {
+		err := s.scan(opts.Watches, func(res ScanResult) {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case results <- res:
+			default:
+			}
+		})
+		close(scanDone)
+		scanErr <- err
+	}()
+
+	go func() {
+		defer stopScan()
+		ticker := time.NewTicker(reg.opts.LostAfter / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case res := <-results:
+				reg.observe(res, time.Now())
+			case now := <-ticker.C:
+				reg.expire(now)
+			case err := <-scanErr:
+				if ctx.Err() != nil {
+					err = nil
+				}
+				reg.finish(err)
+				return
+			}
+		}
+	}()
+	return reg, nil
+}
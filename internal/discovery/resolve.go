@@ -0,0 +1,83 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// ErrControlMACTimeout is returned when the control MAC never shows up in an
+// advertisement after the connect/disconnect cycle.
+var ErrControlMACTimeout = errors.New("control MAC was not advertised")
+
+// defaultResolveTimeout applies when ResolveControlMAC gets a context without a deadline.
+const defaultResolveTimeout = 15 * time.Second
+
+// ResolveControlMAC finds the control MAC of the bud advertising as bleAddr.
+// The control MAC is only broadcast after an L2CAP disconnect, so this
+// connects to the bud, disconnects again and rescans for the updated
+// advertisement.
+func (s *Scanner) ResolveControlMAC(ctx context.Context, bleAddr string) (string, error) {
+	mac, err := bluetooth.ParseMAC(bleAddr)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultResolveTimeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := s.adapter.Enable(); err != nil {
+		return "", err
+	}
+
+	dev, err := s.connect(ctx, mac)
+	if err != nil {
+		return "", fmt.Errorf("resolve control MAC: connect %s: %w", bleAddr, err)
+	}
+	if err := dev.Disconnect(); err != nil {
+		return "", fmt.Errorf("resolve control MAC: disconnect %s: %w", bleAddr, err)
+	}
+
+	h, err := s.FindHeadset(ctx, bleAddr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("resolve control MAC for %s: %w", bleAddr, ErrControlMACTimeout)
+		}
+		return "", err
+	}
+	return h.ControlMAC, nil
+}
+
+type connectResult struct {
+	dev bluetooth.Device
+	err error
+}
+
+// connect connects to mac, returning early when ctx is done. The adapter's
+// Connect cannot be canceled, so a connection that completes afterwards is
+// closed again in the background.
+func (s *Scanner) connect(ctx context.Context, mac bluetooth.MAC) (bluetooth.Device, error) {
+	done := make(chan connectResult, 1)
+	go func() {
+		dev, err := s.adapter.Connect(bluetooth.Address{MACAddress: bluetooth.MACAddress{MAC: mac}}, bluetooth.ConnectionParams{})
+		done <- connectResult{dev, err}
+	}()
+	select {
+	case r := <-done:
+		return r.dev, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.err == nil {
+				_ = r.dev.Disconnect()
+			}
+		}()
+		return bluetooth.Device{}, ctx.Err()
+	}
+}
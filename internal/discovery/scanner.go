@@ -6,11 +6,25 @@ import (
 	"tinygo.org/x/bluetooth"
 )
 
+// ScanResult is one QCY advertisement. Earphones fill Advertisement, watches
+// fill Watch.
 type ScanResult struct {
-	Address         bluetooth.Address
-	RSSI            int16
-	Name            string
-	Advertisement   *utils.AdvertisementInfo
+	Address       bluetooth.Address
+	RSSI          int16
+	Name          string
+	Advertisement *utils.AdvertisementInfo
+	Watch         *utils.WatchAdvertisement
+}
+
+// VendorID returns the advertised product vendor ID of either kind of device.
+func (r ScanResult) VendorID() (uint16, bool) {
+	switch {
+	case r.Advertisement != nil:
+		return r.Advertisement.VendorID, true
+	case r.Watch != nil:
+		return r.Watch.VendorID, true
+	}
+	return 0, false
 }
 
 type Scanner struct {
@@ -21,22 +35,46 @@ func NewScanner(adapter *bluetooth.Adapter) *Scanner {
 	return &Scanner{adapter: adapter}
 }
 
+// Scan calls callback for every QCY earphone advertisement until StopScan is
+// called. Advertisement is always set.
 func (s *Scanner) Scan(callback func(ScanResult)) error {
+	return s.scan(false, callback)
+}
+
+// ScanAll is like Scan but also reports QCY watches, for which Watch is set
+// and Advertisement is nil.
+func (s *Scanner) ScanAll(callback func(ScanResult)) error {
+	return s.scan(true, callback)
+}
+
+func (s *Scanner) scan(watches bool, callback func(ScanResult)) error {
 	return s.adapter.Scan(func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
 		for _, mfr := range result.ManufacturerData() {
-			if mfr.CompanyID != constant.QCYCompanyID {
-				continue
+			res := ScanResult{
+				Address: result.Address,
+				RSSI:    result.RSSI,
+				Name:    result.LocalName(),
 			}
-			info, err := utils.ParseManufacturerData(mfr.Data)
-			if err != nil {
+			switch mfr.CompanyID {
+			case constant.QCYCompanyID:
+				info, err := utils.ParseManufacturerData(mfr.Data)
+				if err != nil {
+					continue
+				}
+				res.Advertisement = info
+			case constant.QCYWatchCompanyID:
+				if !watches {
+					continue
+				}
+				watch, err := utils.ParseWatchManufacturerData(mfr.Data)
+				if err != nil {
+					continue
+				}
+				res.Watch = watch
+			default:
 				continue
 			}
-			callback(ScanResult{
-				Address:       result.Address,
-				RSSI:          result.RSSI,
-				Name:          result.LocalName(),
-				Advertisement: info,
-			})
+			callback(res)
 			return
 		}
 	})
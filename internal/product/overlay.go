@@ -0,0 +1,199 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Overlay adds or corrects database entries. Entries are keyed by vendor ID.
+// An entry for an unknown vendor ID is a full product; an entry for a known
+// one is a JSON merge patch (RFC 7386) onto it, so {"features": {"anc": null}}
+// removes a feature the server lists wrongly.
+type Overlay struct {
+	// Source names the overlay in errors, usually its file path.
+	Source  string
+	Entries map[string]json.RawMessage
+}
+
+// sources records which overlay last changed each vendor ID.
+var sources = make(map[string]string)
+
+// Load reads an overlay file.
+func Load(path string) (Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Overlay{}, err
+	}
+	return ParseOverlay(path, data)
+}
+
+// ParseOverlay decodes an overlay named source.
+func ParseOverlay(source string, data []byte) (Overlay, error) {
+	o := Overlay{Source: source}
+	if err := json.Unmarshal(data, &o.Entries); err != nil {
+		return Overlay{}, fmt.Errorf("%s: %w", source, err)
+	}
+	for key, raw := range o.Entries {
+		if _, err := strconv.ParseUint(key, 10, 16); err != nil {
+			return Overlay{}, fmt.Errorf("%s: vendor ID %q: not a 16-bit number", source, key)
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+			return Overlay{}, fmt.Errorf("%s: vendor ID %s: entry is not an object", source, key)
+		}
+	}
+	return o, nil
+}
+
+// Merge applies overlays to the database. Either all overlays apply or, on
+// error, the database is left unchanged. Two overlays with different sources
+// changing the same vendor ID are a conflict.
+func Merge(overlays ...Overlay) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	db := make(map[string]Product, len(productDatabase))
+	for k, v := range productDatabase {
+		db[k] = v
+	}
+	touched := make(map[string]string)
+	for _, o := range overlays {
+		keys := make([]string, 0, len(o.Entries))
+		for key := range o.Entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if prev, ok := touched[key]; ok && prev != o.Source {
+				return fmt.Errorf("vendor ID %s: set by both %s and %s", key, prev, o.Source)
+			}
+			if prev, ok := sources[key]; ok && prev != o.Source {
+				return fmt.Errorf("vendor ID %s: set by both %s and %s", key, prev, o.Source)
+			}
+			p, err := applyEntry(key, db, o.Entries[key])
+			if err != nil {
+				return fmt.Errorf("%s: vendor ID %s: %w", o.Source, key, err)
+			}
+			db[key] = p
+			touched[key] = o.Source
+		}
+	}
+
+	productDatabase = db
+	for key, source := range touched {
+		sources[key] = source
+	}
+	return nil
+}
+
+func applyEntry(key string, db map[string]Product, patch json.RawMessage) (Product, error) {
+	var base any
+	if p, ok := db[key]; ok {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return Product{}, err
+		}
+		if base, err = decodeJSON(data); err != nil {
+			return Product{}, err
+		}
+	} else {
+		id, _ := strconv.Atoi(key)
+		base = map[string]any{"vendorId": json.Number(strconv.Itoa(id))}
+	}
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return Product{}, err
+	}
+	merged, err := json.Marshal(mergePatch(base, p))
+	if err != nil {
+		return Product{}, err
+	}
+
+	var out Product
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return Product{}, err
+	}
+	if err := checkKey(key, out); err != nil {
+		return Product{}, err
+	}
+	if out.Title == "" {
+		return Product{}, errors.New("missing title")
+	}
+	return out, nil
+}
+
+func decodeJSON(data []byte) (any, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// mergePatch applies an RFC 7386 merge patch: objects merge recursively,
+// null deletes and anything else replaces.
+func mergePatch(target, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = make(map[string]any)
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergePatch(tm[k], v)
+	}
+	return tm
+}
+
+// UserOverlayDir returns the directory user overlays are read from,
+// ~/.config/quicky/products.d on Linux.
+func UserOverlayDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "quicky", "products.d"), nil
+}
+
+// LoadDir merges every *.json overlay in dir, in file name order. A missing
+// directory is not an error.
+func LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	overlays := make([]Overlay, 0, len(paths))
+	for _, path := range paths {
+		o, err := Load(path)
+		if err != nil {
+			return err
+		}
+		overlays = append(overlays, o)
+	}
+	return Merge(overlays...)
+}
+
+// LoadUserOverlays merges the overlays in UserOverlayDir.
+func LoadUserOverlays() error {
+	dir, err := UserOverlayDir()
+	if err != nil {
+		return err
+	}
+	return LoadDir(dir)
+}
@@ -1,31 +1,40 @@
 package product
 
 import (
+	"bytes"
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"sync"
 )
 
 //go:embed products.json
 var productsJSON []byte
 
 type Product struct {
-	VendorId uint16   `json:"vendorId"`
-	Title    string   `json:"title"`
-	SubTitle string   `json:"subTitle"`
-	Category string   `json:"category"`
-	Features Features `json:"features,omitempty"`
+	VendorId uint16 `json:"vendorId"`
+	Title    string `json:"title"`
+	SubTitle string `json:"subTitle"`
+	Category string `json:"category"`
+	// ModelID is a number, but the product list sends it as a string for watches.
+	ModelID  json.Number `json:"modelId,omitempty"`
+	Aliases  []string    `json:"aliases,omitempty"`
+	Features Features    `json:"features,omitempty"`
+	// Annotations are notes from user overlays keyed by feature name, e.g.
+	// for features the QCY server lists wrongly.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 type Features struct {
-	ANC            *ANCFeature      `json:"anc,omitempty"`
-	EQ             *EQFeature       `json:"eq,omitempty"`
-	KeyFunction    *KeyFuncFeature  `json:"key_function,omitempty"`
-	ChannelBalance bool             `json:"channel_balance,omitempty"`
-	FindEarphone   bool             `json:"find_earphone,omitempty"`
-	DeviceName     bool             `json:"device_name,omitempty"`
-	AutoOffTimer   *AutoOffFeature  `json:"auto_off_timer,omitempty"`
-	Settings       []SettingItem    `json:"settings,omitempty"`
+	ANC            *ANCFeature     `json:"anc,omitempty"`
+	EQ             *EQFeature      `json:"eq,omitempty"`
+	KeyFunction    *KeyFuncFeature `json:"key_function,omitempty"`
+	ChannelBalance bool            `json:"channel_balance,omitempty"`
+	FindEarphone   bool            `json:"find_earphone,omitempty"`
+	DeviceName     bool            `json:"device_name,omitempty"`
+	AutoOffTimer   *AutoOffFeature `json:"auto_off_timer,omitempty"`
+	Settings       []SettingItem   `json:"settings,omitempty"`
 }
 
 type ANCFeature struct {
@@ -33,12 +42,12 @@ type ANCFeature struct {
 }
 
 type ANCMode struct {
-	Name       string     `json:"name"`
-	StartCmdID int        `json:"startcmdid"`
-	EndCmdID   int        `json:"endcmdid"`
-	DefaultCmd int        `json:"defaultcmd"`
-	ViewType   int        `json:"viewtype"`
-	Items      []ANCItem  `json:"items,omitempty"`
+	Name       string    `json:"name"`
+	StartCmdID int       `json:"startcmdid"`
+	EndCmdID   int       `json:"endcmdid"`
+	DefaultCmd int       `json:"defaultcmd"`
+	ViewType   int       `json:"viewtype"`
+	Items      []ANCItem `json:"items,omitempty"`
 }
 
 type ANCItem struct {
@@ -77,25 +86,63 @@ type SettingItem struct {
 	Cmd   string `json:"cmd,omitempty"`
 }
 
-var productDatabase map[string]Product
+var (
+	mu              sync.RWMutex
+	productDatabase map[string]Product
+	// embeddedErr is set if the embedded database does not parse; the
+	// database then starts empty so overlays can still be loaded.
+	embeddedErr error
+)
 
 func init() {
-	productDatabase = make(map[string]Product)
-	if err := json.Unmarshal(productsJSON, &productDatabase); err != nil {
-		panic("failed to load product database: " + err.Error())
+	db, err := parseDatabase(productsJSON)
+	if err != nil {
+		embeddedErr = fmt.Errorf("embedded product database: %w", err)
+		db = make(map[string]Product)
 	}
+	productDatabase = db
 }
 
-func Lookup(vendorId uint16) (*Product, bool) {
-	key := strconv.Itoa(int(vendorId))
-	p, ok := productDatabase[key]
-	if !ok {
-		return nil, false
+// parseDatabase decodes a database keyed by vendor ID, rejecting unknown
+// fields and keys that do not match the entry's vendor ID.
+func parseDatabase(data []byte) (map[string]Product, error) {
+	db := make(map[string]Product)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&db); err != nil {
+		return nil, err
 	}
-	return &p, true
+	for key, p := range db {
+		if err := checkKey(key, p); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+func checkKey(key string, p Product) error {
+	id, err := strconv.ParseUint(key, 10, 16)
+	if err != nil {
+		return fmt.Errorf("vendor ID %q: not a 16-bit number", key)
+	}
+	if uint16(id) != p.VendorId {
+		return fmt.Errorf("vendor ID %q: entry has vendorId %d", key, p.VendorId)
+	}
+	return nil
+}
+
+// EmbeddedError reports whether the embedded database failed to load.
+func EmbeddedError() error {
+	return embeddedErr
+}
+
+func Lookup(vendorId uint16) (*Product, bool) {
+	return LookupByString(strconv.Itoa(int(vendorId)))
 }
 
 func LookupByString(vendorIdStr string) (*Product, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
 	p, ok := productDatabase[vendorIdStr]
 	if !ok {
 		return nil, false
@@ -104,5 +151,7 @@ func LookupByString(vendorIdStr string) (*Product, bool) {
 }
 
 func Count() int {
+	mu.RLock()
+	defer mu.RUnlock()
 	return len(productDatabase)
 }
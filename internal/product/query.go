@@ -0,0 +1,158 @@
+package product
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Categories used by the QCY product list. Watches really are spelled
+// "wactchInfos" by the server.
+const (
+	CategoryEarphones = "earphones"
+	CategoryWatches   = "wactchInfos"
+	CategoryAccessory = "accessory"
+	CategorySpeaker   = "speaker"
+)
+
+// HasANC reports whether the model has noise cancellation modes.
+func (p Product) HasANC() bool {
+	return p.Features.ANC != nil && len(p.Features.ANC.Modes) > 0
+}
+
+// EQBands returns the number of EQ bands, or 0 without an equalizer.
+func (p Product) EQBands() int {
+	if p.Features.EQ == nil {
+		return 0
+	}
+	return p.Features.EQ.Bands
+}
+
+// HasKeyFunction reports whether the touch controls can be remapped.
+func (p Product) HasKeyFunction() bool {
+	return p.Features.KeyFunction != nil && len(p.Features.KeyFunction.Events) > 0
+}
+
+// HasSetting reports whether the settings panel has an item whose name or
+// type matches, ignoring case, e.g. "game_mode" or "Dual device connection".
+func (p Product) HasSetting(name string) bool {
+	for _, s := range p.Features.Settings {
+		if strings.EqualFold(s.Name, name) || strings.EqualFold(s.Type, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns every product, ordered by vendor ID.
+func All() []Product {
+	return Filter(func(Product) bool { return true })
+}
+
+// Filter returns the products fn accepts, ordered by vendor ID.
+func Filter(fn func(Product) bool) []Product {
+	mu.RLock()
+	out := make([]Product, 0, len(productDatabase))
+	for _, p := range productDatabase {
+		if fn(p) {
+			out = append(out, p)
+		}
+	}
+	mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].VendorId < out[j].VendorId })
+	return out
+}
+
+// ByCategory returns the products of a category, ignoring case. "watches"
+// is accepted for CategoryWatches.
+func ByCategory(category string) []Product {
+	if strings.EqualFold(category, "watches") || strings.EqualFold(category, "watch") {
+		category = CategoryWatches
+	}
+	return Filter(func(p Product) bool { return strings.EqualFold(p.Category, category) })
+}
+
+// Search returns the products whose title, subtitle or aliases match query,
+// best match first. Every word of the query must match, either as a
+// substring or, with a lower score, as a subsequence ("ht16" matches
+// "HT-16"). Case and punctuation are ignored.
+func Search(query string) []Product {
+	words := strings.Fields(normalize(query))
+	if len(words) == 0 {
+		return nil
+	}
+	type hit struct {
+		p     Product
+		score int
+	}
+	var hits []hit
+	for _, p := range All() {
+		if score := matchScore(p, words); score > 0 {
+			hits = append(hits, hit{p, score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	out := make([]Product, len(hits))
+	for i, h := range hits {
+		out[i] = h.p
+	}
+	return out
+}
+
+// normalize lowercases s and turns punctuation into spaces.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+}
+
+func matchScore(p Product, words []string) int {
+	fields := append([]string{p.Title, p.SubTitle}, p.Aliases...)
+	total := 0
+	for _, w := range words {
+		best := 0
+		for _, f := range fields {
+			if s := wordScore(normalize(f), w); s > best {
+				best = s
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// wordScore rates how well w occurs in field: a whole word beats a
+// substring, which beats a subsequence of the field without spaces.
+func wordScore(field, w string) int {
+	for _, f := range strings.Fields(field) {
+		if f == w {
+			return 4
+		}
+	}
+	switch {
+	case strings.Contains(field, w):
+		return 3
+	case strings.Contains(strings.ReplaceAll(field, " ", ""), w):
+		return 2
+	case isSubsequence(strings.ReplaceAll(field, " ", ""), w):
+		return 1
+	}
+	return 0
+}
+
+func isSubsequence(s, sub string) bool {
+	want := []rune(sub)
+	i := 0
+	for _, r := range s {
+		if i < len(want) && r == want[i] {
+			i++
+		}
+	}
+	return i == len(want)
+}
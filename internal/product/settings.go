@@ -0,0 +1,197 @@
+package product
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SettingTypes names the type codes of control panel settings items as they
+// are stored in SettingItem.Type. The names are historical: 200 is a generic
+// switch that also carries dual connection, LDAC and more. Unknown codes are
+// stored as "type_<code>".
+var SettingTypes = map[int]string{
+	1:   "firmware_update",
+	3:   "reset_default",
+	100: "factory_reset",
+	200: "sleep_mode",
+	203: "game_mode",
+}
+
+// SettingTypeName returns the SettingItem.Type stored for a type code.
+func SettingTypeName(code int) string {
+	if name, ok := SettingTypes[code]; ok {
+		return name
+	}
+	return "type_" + strconv.Itoa(code)
+}
+
+// TypeCode returns the panel type code of the item.
+func (s SettingItem) TypeCode() (int, bool) {
+	for code, name := range SettingTypes {
+		if s.Type == name {
+			return code, true
+		}
+	}
+	code, err := strconv.ParseFloat(strings.TrimPrefix(s.Type, "type_"), 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(code), true
+}
+
+type SettingKind int
+
+const (
+	// SettingAction runs once, e.g. a reset. It takes no value.
+	SettingAction SettingKind = iota
+	// SettingToggle is on (0x01) or off (0x02).
+	SettingToggle
+	// SettingText is a free-form value sent as text, e.g. a voice language
+	// code.
+	SettingText
+	// SettingSlider is any byte; the control panels give no range.
+	SettingSlider
+)
+
+func (k SettingKind) String() string {
+	switch k {
+	case SettingAction:
+		return "action"
+	case SettingToggle:
+		return "toggle"
+	case SettingText:
+		return "text"
+	case SettingSlider:
+		return "slider"
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+func (k SettingKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Frame is a complete command packet, shown as hex.
+type Frame []byte
+
+func (f Frame) String() string {
+	return strings.ToUpper(hex.EncodeToString(f))
+}
+
+func (f Frame) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// Setting is a settings item normalised to what it does on the device.
+type Setting struct {
+	Name string      `json:"name"`
+	Kind SettingKind `json:"kind"`
+	// Opcode is the command the setting drives, 0 if it has none.
+	Opcode byte `json:"opcode,omitempty"`
+	// Frame is the complete packet an action sends, from the item's cmd.
+	Frame Frame `json:"frame,omitempty"`
+	// Item is the settings item from the control panel.
+	Item SettingItem `json:"item"`
+}
+
+// Settable reports whether the library knows what to send for the setting.
+func (s Setting) Settable() bool {
+	return s.Opcode != 0 || len(s.Frame) > 0
+}
+
+// opcodeSettings describes opcodes that are not plain toggles. The control
+// panels carry no ranges or value names, so these take a raw byte.
+var opcodeSettings = map[byte]Setting{
+	0x07: {Kind: SettingSlider},
+	0x11: {Kind: SettingAction, Frame: Frame{0xff, 0x04, 0x11, 0x02, 0x01, 0x01}},
+	0x16: {Kind: SettingSlider},
+	0x19: {Kind: SettingText},
+	0x1d: {Kind: SettingSlider},
+	0x2e: {Kind: SettingSlider},
+	0x48: {Kind: SettingSlider},
+}
+
+// typeOpcodes are the opcodes of panel types that come without a cmdid.
+var typeOpcodes = map[int]byte{
+	2:   0x19, // voice language
+	201: 0x06, // in-ear detection
+	301: 0x11, // ear tip fit test
+}
+
+// nameOpcodes recognise items by title when neither cmdid nor type help.
+var nameOpcodes = map[string]byte{
+	"in-ear sensor":    0x06,
+	"in-ear detection": 0x06,
+}
+
+// parseFrame decodes an item's cmd: either a full hex frame such as
+// "FF020100" or just the opcode, as in "01".
+func parseFrame(cmd string) (Frame, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(cmd))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(b) == 1:
+		return Frame{0xff, 0x02, b[0], 0x00}, nil
+	case len(b) >= 4 && b[0] == 0xff && int(b[1])+2 == len(b):
+		return Frame(b), nil
+	}
+	return nil, fmt.Errorf("invalid frame %q", cmd)
+}
+
+// Normalize maps a control panel item to the setting it drives.
+func (s SettingItem) Normalize() Setting {
+	out := Setting{Name: s.Name, Kind: SettingToggle, Item: s}
+	code, _ := s.TypeCode()
+
+	if s.Cmd != "" {
+		out.Kind = SettingAction
+		if frame, err := parseFrame(s.Cmd); err == nil {
+			out.Frame = frame
+			out.Opcode = frame[2]
+		}
+		return out
+	}
+
+	switch {
+	case s.CmdID != nil && *s.CmdID > 0 && *s.CmdID <= 0xff:
+		out.Opcode = byte(*s.CmdID)
+	case typeOpcodes[code] != 0:
+		out.Opcode = typeOpcodes[code]
+	default:
+		out.Opcode = nameOpcodes[strings.ToLower(s.Name)]
+	}
+	if out.Opcode == 0 {
+		// Firmware update, version and reset items without a cmd are
+		// handled by the app itself.
+		out.Kind = SettingAction
+		return out
+	}
+	if known, ok := opcodeSettings[out.Opcode]; ok {
+		out.Kind = known.Kind
+		out.Frame = known.Frame
+	}
+	return out
+}
+
+// Settings returns the model's settings items, normalised.
+func (p Product) Settings() []Setting {
+	out := make([]Setting, len(p.Features.Settings))
+	for i, s := range p.Features.Settings {
+		out[i] = s.Normalize()
+	}
+	return out
+}
+
+// Setting returns the setting with the given name, ignoring case.
+func (p Product) Setting(name string) (Setting, bool) {
+	for _, s := range p.Settings() {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
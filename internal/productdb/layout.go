@@ -0,0 +1,216 @@
+package productdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hui1601/Quicky/internal/product"
+)
+
+// LayoutTypes names the layout type codes of the control panel JSONs.
+var LayoutTypes = map[int]string{
+	2:   "eq",
+	3:   "find_earphone",
+	4:   "settings",
+	8:   "channel_balance",
+	9:   "anc",
+	10:  "key_function",
+	100: "reset",
+	101: "device_name",
+	102: "auto_off_timer",
+}
+
+// number is a JSON number that may also arrive as a string, a float or null.
+type number struct {
+	v     float64
+	valid bool
+}
+
+func (n *number) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" || s == "" {
+		*n = number{}
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*n = number{v: v, valid: true}
+	return nil
+}
+
+func (n number) int() int {
+	return int(math.Round(n.v))
+}
+
+func (n number) intPtr() *int {
+	if !n.valid {
+		return nil
+	}
+	v := n.int()
+	return &v
+}
+
+type rawNamed struct {
+	Name string `json:"name"`
+}
+
+type rawANCItem struct {
+	Name       string `json:"name"`
+	StartCmdID number `json:"startcmdid"`
+	EndCmdID   number `json:"endcmdid"`
+}
+
+type rawANCMode struct {
+	Name       string       `json:"name"`
+	StartCmdID number       `json:"startcmdid"`
+	EndCmdID   number       `json:"endcmdid"`
+	DefaultCmd number       `json:"defaultcmd"`
+	ViewType   number       `json:"viewtype"`
+	Items      []rawANCItem `json:"items"`
+}
+
+type rawSettingItem struct {
+	Type  number          `json:"type"`
+	Title string          `json:"title"`
+	CmdID number          `json:"cmdid"`
+	Cmd   json.RawMessage `json:"cmd"`
+}
+
+type rawKeyEvent struct {
+	Name string `json:"name"`
+	Left struct {
+		List []rawNamed `json:"list"`
+	} `json:"left"`
+}
+
+type rawLayout struct {
+	Type number `json:"type"`
+
+	// ANC
+	Modes []rawANCMode `json:"modes"`
+
+	// EQ
+	Count     number     `json:"count"`
+	MinDB     number     `json:"mindb"`
+	MaxDB     number     `json:"maxdb"`
+	Freq      string     `json:"freq"`
+	Character string     `json:"character"`
+	SysEQ     []rawNamed `json:"sys_eq"`
+
+	// Key function
+	Music struct {
+		Event []rawKeyEvent `json:"event"`
+	} `json:"music"`
+
+	// Settings
+	Items []rawSettingItem `json:"items"`
+
+	// Auto off timer
+	CmdID  number `json:"cmdid"`
+	Repeat number `json:"repeat"`
+}
+
+// FeaturesFromLayouts maps control panel layouts to product features.
+// Layouts that do not describe a feature (e.g. reset) are ignored.
+func FeaturesFromLayouts(layouts []json.RawMessage) (product.Features, error) {
+	var f product.Features
+	for i, raw := range layouts {
+		var l rawLayout
+		if err := json.Unmarshal(raw, &l); err != nil {
+			return product.Features{}, fmt.Errorf("layout %d: %w", i, err)
+		}
+		switch LayoutTypes[l.Type.int()] {
+		case "anc":
+			f.ANC = ancFeature(l.Modes)
+		case "eq":
+			f.EQ = eqFeature(l)
+		case "key_function":
+			f.KeyFunction = keyFuncFeature(l.Music.Event)
+		case "channel_balance":
+			f.ChannelBalance = true
+		case "find_earphone":
+			f.FindEarphone = true
+		case "settings":
+			f.Settings = settingItems(l.Items)
+		case "device_name":
+			f.DeviceName = true
+		case "auto_off_timer":
+			f.AutoOffTimer = &product.AutoOffFeature{CmdID: l.CmdID.int(), Repeat: l.Repeat.int()}
+		}
+	}
+	return f, nil
+}
+
+func ancFeature(modes []rawANCMode) *product.ANCFeature {
+	anc := &product.ANCFeature{Modes: make([]product.ANCMode, 0, len(modes))}
+	for _, m := range modes {
+		mode := product.ANCMode{
+			Name:       m.Name,
+			StartCmdID: m.StartCmdID.int(),
+			EndCmdID:   m.EndCmdID.int(),
+			DefaultCmd: m.DefaultCmd.int(),
+			ViewType:   m.ViewType.int(),
+		}
+		for _, it := range m.Items {
+			mode.Items = append(mode.Items, product.ANCItem{
+				Name:       it.Name,
+				StartCmdID: it.StartCmdID.int(),
+				EndCmdID:   it.EndCmdID.int(),
+			})
+		}
+		anc.Modes = append(anc.Modes, mode)
+	}
+	return anc
+}
+
+func eqFeature(l rawLayout) *product.EQFeature {
+	bands := 10
+	if l.Count.valid {
+		bands = l.Count.int()
+	}
+	eq := &product.EQFeature{
+		Bands:          bands,
+		MinDB:          l.MinDB.int(),
+		MaxDB:          l.MaxDB.int(),
+		Freq:           l.Freq,
+		Characteristic: l.Character,
+		Presets:        make([]string, 0, len(l.SysEQ)),
+	}
+	for _, p := range l.SysEQ {
+		eq.Presets = append(eq.Presets, p.Name)
+	}
+	return eq
+}
+
+func keyFuncFeature(events []rawKeyEvent) *product.KeyFuncFeature {
+	kf := &product.KeyFuncFeature{Events: make([]product.KeyEvent, 0, len(events))}
+	for _, ev := range events {
+		functions := make([]string, 0, len(ev.Left.List))
+		for _, fn := range ev.Left.List {
+			functions = append(functions, fn.Name)
+		}
+		kf.Events = append(kf.Events, product.KeyEvent{Name: ev.Name, Functions: functions})
+	}
+	return kf
+}
+
+func settingItems(items []rawSettingItem) []product.SettingItem {
+	settings := make([]product.SettingItem, 0, len(items))
+	for _, it := range items {
+		var cmd string
+		// cmd is a hex frame string; anything else is dropped.
+		json.Unmarshal(it.Cmd, &cmd)
+		settings = append(settings, product.SettingItem{
+			Name:  it.Title,
+			Type:  product.SettingTypeName(it.Type.int()),
+			CmdID: it.CmdID.intPtr(),
+			Cmd:   cmd,
+		})
+	}
+	return settings
+}
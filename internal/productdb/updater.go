@@ -0,0 +1,359 @@
+// Package productdb rebuilds the embedded product database from the QCY
+// product API.
+package productdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/product"
+)
+
+const DefaultBaseURL = "https://api.watch.qcy.com/"
+
+// Categories are the product list keys that are collected, in order.
+var Categories = []string{"earphones", "wactchInfos", "accessory", "speaker", "product"}
+
+// Updater fetches the product list and control panels. The zero value talks
+// to DefaultBaseURL with http.DefaultClient.
+type Updater struct {
+	// BaseURL of the product API, e.g. a local stand-in server.
+	BaseURL string
+	Client  *http.Client
+	// Lang, Country and AppVersion are sent as request headers.
+	// They default to "en", "US" and "4.0.7_695".
+	Lang       string
+	Country    string
+	AppVersion string
+	// PanelDelay is the pause between control panel requests.
+	PanelDelay time.Duration
+	// SkipPanels only builds the product list, without features.
+	SkipPanels bool
+	// Logf, if set, receives progress and warnings.
+	Logf func(format string, args ...any)
+}
+
+func (u *Updater) baseURL() string {
+	if u.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimSuffix(u.BaseURL, "/") + "/"
+}
+
+func (u *Updater) client() *http.Client {
+	if u.Client == nil {
+		return http.DefaultClient
+	}
+	return u.Client
+}
+
+func (u *Updater) logf(format string, args ...any) {
+	if u.Logf != nil {
+		u.Logf(format, args...)
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func (u *Updater) setHeaders(req *http.Request) {
+	req.Header.Set("lang", orDefault(u.Lang, "en"))
+	req.Header.Set("country", orDefault(u.Country, "US"))
+	req.Header.Set("sys_", "android")
+	req.Header.Set("app_version", orDefault(u.AppVersion, "4.0.7_695"))
+}
+
+func (u *Updater) do(req *http.Request) ([]byte, error) {
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	return body, nil
+}
+
+// FetchProductList returns the raw product list. The API answers with the
+// URL of a ZIP archive that holds the list as a JSON file.
+func (u *Updater) FetchProductList(ctx context.Context) (json.RawMessage, error) {
+	endpoint := u.baseURL() + "product/findProductList"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	u.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	u.logf("POST %s", endpoint)
+	body, err := u.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return nil, fmt.Errorf("product list: %w", err)
+	}
+	var zipURL string
+	var data struct {
+		Product string `json:"product"`
+	}
+	if json.Unmarshal(meta.Data, &data) == nil {
+		zipURL = data.Product
+	} else {
+		json.Unmarshal(meta.Data, &zipURL)
+	}
+	if zipURL == "" {
+		u.logf("no product ZIP URL in response, using it as the product list")
+		return body, nil
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, zipURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	u.logf("GET %s", zipURL)
+	archive, err := u.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return firstJSON(archive)
+}
+
+// firstJSON returns the first JSON file in a ZIP archive.
+func firstJSON(archive []byte) (json.RawMessage, error) {
+	z, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("product list archive: %w", err)
+	}
+	for _, f := range z.File {
+		if !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, errors.New("product list archive: no JSON file")
+}
+
+// FetchControlPanel returns the layouts of a model's control panel, or nil if
+// the API has none for it.
+func (u *Updater) FetchControlPanel(ctx context.Context, vendorID int) ([]json.RawMessage, error) {
+	endpoint := u.baseURL() + "product/findControlPanels"
+	form := url.Values{"modelId": {strconv.Itoa(vendorID)}, "firmwareVersion": {""}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	u.setHeaders(req)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	u.logf("POST %s vendorId=%d", endpoint, vendorID)
+	body, err := u.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Code number `json:"code"`
+		Data struct {
+			ControlPanel struct {
+				Layouts []json.RawMessage `json:"layouts"`
+			} `json:"controlPanel"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("control panel %d: %w", vendorID, err)
+	}
+	if resp.Code.int() != 200 {
+		return nil, nil
+	}
+	return resp.Data.ControlPanel.Layouts, nil
+}
+
+type rawProduct struct {
+	VendorID  number `json:"vendorId"`
+	VendorID2 number `json:"vendorID"`
+	Title     string `json:"title"`
+	SubTitle  string `json:"subTitle"`
+	ModelID   number `json:"modelId"`
+	FlageID   number `json:"flageID"`
+	ID        number `json:"id"`
+}
+
+func firstNumber(ns ...number) (int, bool) {
+	for _, n := range ns {
+		if n.valid && n.v != 0 {
+			return n.int(), true
+		}
+	}
+	return 0, false
+}
+
+type listItem struct {
+	category string
+	raw      rawProduct
+}
+
+// productItems collects the items of every category of a product list.
+func productItems(list json.RawMessage) ([]listItem, error) {
+	var wrapped struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(list, &wrapped) == nil && len(wrapped.Data) > 0 {
+		list = wrapped.Data
+	}
+
+	var flat []rawProduct
+	if json.Unmarshal(list, &flat) == nil {
+		items := make([]listItem, len(flat))
+		for i, p := range flat {
+			items[i] = listItem{category: "unknown", raw: p}
+		}
+		return items, nil
+	}
+
+	var byCategory map[string]json.RawMessage
+	if err := json.Unmarshal(list, &byCategory); err != nil {
+		return nil, fmt.Errorf("product list: %w", err)
+	}
+	var items []listItem
+	for _, category := range Categories {
+		var ps []rawProduct
+		if raw, ok := byCategory[category]; ok {
+			if err := json.Unmarshal(raw, &ps); err != nil {
+				return nil, fmt.Errorf("product list %s: %w", category, err)
+			}
+		}
+		for _, p := range ps {
+			items = append(items, listItem{category: category, raw: p})
+		}
+	}
+	return items, nil
+}
+
+// Build turns a product list into a database keyed by vendor ID, fetching
+// the control panel of every earphone unless SkipPanels is set. A missing or
+// failing control panel only leaves that model without features. Duplicate
+// vendor IDs keep the first entry and record the other titles as aliases.
+func (u *Updater) Build(ctx context.Context, list json.RawMessage) (map[string]product.Product, error) {
+	items, err := productItems(list)
+	if err != nil {
+		return nil, err
+	}
+	u.logf("found %d products", len(items))
+
+	products := make(map[string]product.Product)
+	for _, it := range items {
+		vendorID, ok := firstNumber(it.raw.VendorID, it.raw.VendorID2)
+		if !ok {
+			continue
+		}
+		key := strconv.Itoa(vendorID)
+		title := orDefault(it.raw.Title, "Unknown")
+		if existing, dup := products[key]; dup {
+			existing.Aliases = append(existing.Aliases, title)
+			products[key] = existing
+			continue
+		}
+
+		p := product.Product{
+			VendorId: uint16(vendorID),
+			Title:    title,
+			SubTitle: it.raw.SubTitle,
+			Category: it.category,
+		}
+		if modelID, ok := firstNumber(it.raw.ModelID, it.raw.FlageID, it.raw.ID); ok {
+			p.ModelID = json.Number(strconv.Itoa(modelID))
+		}
+
+		if !u.SkipPanels && (it.category == "earphones" || it.category == "unknown") {
+			if err := u.addFeatures(ctx, &p, vendorID); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				u.logf("WARN: vendorId=%d: %v", vendorID, err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(u.PanelDelay):
+			}
+		}
+		products[key] = p
+	}
+	return products, nil
+}
+
+func (u *Updater) addFeatures(ctx context.Context, p *product.Product, vendorID int) error {
+	layouts, err := u.FetchControlPanel(ctx, vendorID)
+	if err != nil || len(layouts) == 0 {
+		return err
+	}
+	features, err := FeaturesFromLayouts(layouts)
+	if err != nil {
+		return err
+	}
+	p.Features = features
+	return nil
+}
+
+// Update fetches the product list and builds the database.
+func (u *Updater) Update(ctx context.Context) (map[string]product.Product, error) {
+	list, err := u.FetchProductList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.Build(ctx, list)
+}
+
+// Marshal encodes a database deterministically: keys sorted, two-space
+// indent, no HTML escaping and a trailing newline.
+func Marshal(products map[string]product.Product) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(products); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFile writes the database to path, replacing it atomically.
+func WriteFile(path string, products map[string]product.Product) error {
+	data, err := Marshal(products)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,164 @@
+package productdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testProductList = `{
+  "earphones": [
+    {"vendorId": 100, "title": "T13", "subTitle": "TWS", "modelId": 7},
+    {"vendorID": "100", "title": "T13 ANC"},
+    {"vendorId": 200, "title": "HT05", "flageID": 9}
+  ],
+  "wactchInfos": [
+    {"vendorId": 300, "title": "GS", "modelId": "12"}
+  ]
+}`
+
+const testControlPanel = `{
+  "code": 200,
+  "data": {"controlPanel": {"layouts": [
+    {"type": 3},
+    {"type": 9, "modes": [{"name": "ANC", "startcmdid": 1, "endcmdid": 1, "defaultcmd": 1, "viewtype": 0}]}
+  ]}}
+}`
+
+func zipped(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	w, err := z.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(content))
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newTestAPI serves the product list as a ZIP archive and a control panel
+// for vendor ID 100. It records the vendor IDs of control panel requests.
+func newTestAPI(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var panels []string
+	archive := zipped(t, "products.json", testProductList)
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/product/findProductList", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("sys_") != "android" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"code": 200,
+			"data": map[string]string{"product": srv.URL + "/list.zip"},
+		})
+	})
+	mux.HandleFunc("/list.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/product/findControlPanels", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id := r.PostForm.Get("modelId")
+		panels = append(panels, id)
+		if id != "100" {
+			w.Write([]byte(`{"code": 404}`))
+			return
+		}
+		w.Write([]byte(testControlPanel))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &panels
+}
+
+func TestFetchProductList(t *testing.T) {
+	srv, _ := newTestAPI(t)
+	u := &Updater{BaseURL: srv.URL}
+	list, err := u.FetchProductList(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(list) || !bytes.Contains(list, []byte(`"T13"`)) {
+		t.Fatalf("list = %s", list)
+	}
+}
+
+func TestFetchProductListHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	u := &Updater{BaseURL: srv.URL}
+	if _, err := u.FetchProductList(context.Background()); err == nil {
+		t.Fatal("expected an error for a 404 response")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	srv, panels := newTestAPI(t)
+	u := &Updater{BaseURL: srv.URL}
+	products, err := u.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 3 {
+		t.Fatalf("got %d products, want 3", len(products))
+	}
+
+	t13 := products["100"]
+	if t13.Title != "T13" || t13.Category != "earphones" || t13.ModelID != "7" {
+		t.Errorf("100 = %+v", t13)
+	}
+	if len(t13.Aliases) != 1 || t13.Aliases[0] != "T13 ANC" {
+		t.Errorf("100 aliases = %v, want [T13 ANC]", t13.Aliases)
+	}
+	if !t13.Features.FindEarphone || t13.Features.ANC == nil || len(t13.Features.ANC.Modes) != 1 {
+		t.Errorf("100 features = %+v", t13.Features)
+	}
+
+	ht05 := products["200"]
+	if ht05.ModelID != "9" || ht05.Features.ANC != nil {
+		t.Errorf("200 = %+v", ht05)
+	}
+	if watch := products["300"]; watch.Category != "wactchInfos" || watch.ModelID != "12" {
+		t.Errorf("300 = %+v", watch)
+	}
+
+	// Watches do not have control panels, duplicates are fetched once.
+	if got := *panels; len(got) != 2 || got[0] != "100" || got[1] != "200" {
+		t.Errorf("control panel requests = %v, want [100 200]", got)
+	}
+}
+
+func TestBuildSkipPanels(t *testing.T) {
+	srv, panels := newTestAPI(t)
+	u := &Updater{BaseURL: srv.URL, SkipPanels: true}
+	products, err := u.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 3 || len(*panels) != 0 {
+		t.Fatalf("got %d products and %d panel requests", len(products), len(*panels))
+	}
+}
+
+func TestBuildCanceled(t *testing.T) {
+	srv, _ := newTestAPI(t)
+	u := &Updater{BaseURL: srv.URL}
+	list, err := u.FetchProductList(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := u.Build(ctx, list); err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
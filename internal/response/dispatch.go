@@ -33,6 +33,7 @@ const (
 	EventEQV1             EventType = 0x20
 	EventEQV2             EventType = 0x22
 	EventLDAC             EventType = 0x23
+	EventDualConnection   EventType = 0x24
 	EventAdaptiveEQ       EventType = 0x27
 	EventANCResult        EventType = 0x28
 	EventANCWear          EventType = 0x29
@@ -92,6 +93,9 @@ func Dispatch(cmdID byte, params []byte) Event {
 	case 0x17:
 		v, err := ParseANCSetting(params)
 		ev.Parsed, ev.Error = v, err
+	case 0x24:
+		v, err := ParseDualConnection(params)
+		ev.Parsed, ev.Error = v, err
 	case 0x14:
 		v, err := ParsePowerManager(params)
 		ev.Parsed, ev.Error = v, err
@@ -122,6 +126,9 @@ func Dispatch(cmdID byte, params []byte) Event {
 	case 0x36:
 		v, err := ParseLEDEffect(params)
 		ev.Parsed, ev.Error = v, err
+	case 0x3E:
+		v, err := ParseSyncTime(params)
+		ev.Parsed, ev.Error = v, err
 	case 0x3F:
 		v, err := ParseAlarmList(params)
 		ev.Parsed, ev.Error = v, err
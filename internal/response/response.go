@@ -4,6 +4,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"image/color"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/command"
 )
 
 type Battery struct {
@@ -120,6 +123,18 @@ func ParseToneVolume(params []byte) (ToneVolume, error) {
 	return tv, nil
 }
 
+// DualConnection is the multipoint state (cmd 0x24).
+type DualConnection struct {
+	Enabled bool
+}
+
+func ParseDualConnection(params []byte) (DualConnection, error) {
+	if len(params) < 1 {
+		return DualConnection{}, fmt.Errorf("dual connection: need at least 1 byte, got %d", len(params))
+	}
+	return DualConnection{Enabled: params[0] == 0x01}, nil
+}
+
 type WearingDetection struct {
 	Enabled    bool
 	MusicIndex byte
@@ -260,12 +275,44 @@ func ParseLEDEffect(params []byte) (LEDEffect, error) {
 	return effect, nil
 }
 
+type SyncTime struct {
+	Year     byte // year mod 100
+	Month    byte
+	Day      byte
+	Hour     byte
+	Minute   byte
+	Second   byte
+	Weekdays Weekdays
+}
+
+func ParseSyncTime(params []byte) (SyncTime, error) {
+	if len(params) < 7 {
+		return SyncTime{}, fmt.Errorf("sync time: need 7 bytes, got %d", len(params))
+	}
+	return SyncTime{
+		Year:     params[0],
+		Month:    params[1],
+		Day:      params[2],
+		Hour:     params[3],
+		Minute:   params[4],
+		Second:   params[5],
+		Weekdays: Weekdays(params[6]),
+	}, nil
+}
+
+// Time returns the reported time in loc, assuming a year in the 2000s.
+func (t SyncTime) Time(loc *time.Location) time.Time {
+	return time.Date(2000+int(t.Year), time.Month(t.Month), int(t.Day),
+		int(t.Hour), int(t.Minute), int(t.Second), 0, loc)
+}
+
 type Alarm struct {
 	AlarmID byte
 	Enabled bool
 	Hour    byte
 	Minute  byte
-	Cycle   byte
+	Cycle   Weekdays
+	Index   byte // trailing byte; 0x05 for alarms created with the add command
 }
 
 func ParseAlarmList(params []byte) ([]Alarm, error) {
@@ -280,7 +327,8 @@ func ParseAlarmList(params []byte) ([]Alarm, error) {
 			Enabled: data[1] == 0x01,
 			Hour:    data[2],
 			Minute:  data[3],
-			Cycle:   data[4],
+			Cycle:   Weekdays(data[4]),
+			Index:   data[5],
 		})
 		data = data[6:]
 	}
@@ -290,7 +338,7 @@ func ParseAlarmList(params []byte) ([]Alarm, error) {
 type MusicStatus struct {
 	MusicID   uint32
 	IsPlaying bool
-	PlayMode  byte
+	PlayMode  command.PlayMode
 }
 
 func ParseMusicStatus(params []byte) (MusicStatus, error) {
@@ -300,7 +348,7 @@ func ParseMusicStatus(params []byte) (MusicStatus, error) {
 	return MusicStatus{
 		MusicID:   binary.LittleEndian.Uint32(params[0:4]),
 		IsPlaying: params[4] == 0x01,
-		PlayMode:  params[5],
+		PlayMode:  command.PlayMode(params[5]),
 	}, nil
 }
 
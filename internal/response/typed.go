@@ -0,0 +1,374 @@
+package response
+
+import "github.com/hui1601/Quicky/internal/command"
+
+// TypedEvent is implemented by the per-opcode event payloads returned by Typed.
+type TypedEvent interface {
+	EventType() EventType
+}
+
+// UnknownEvent carries a notification without a typed payload.
+type UnknownEvent struct {
+	CmdID byte
+	Raw   []byte
+}
+
+func (UnknownEvent) EventType() EventType { return EventUnknown }
+
+type ResetDefaultEvent struct{}
+
+type ClearPairingEvent struct{}
+
+type FactoryResetEvent struct{}
+
+type MusicControlEvent struct {
+	Action byte
+}
+
+type LightFlashEvent struct {
+	On bool
+}
+
+type InEarTestEvent struct {
+	Enabled bool
+}
+
+type NoiseValueEvent struct {
+	Value byte
+}
+
+type VolumeEvent struct {
+	Volume
+}
+
+type LowLatencyEvent struct {
+	Enabled bool
+}
+
+type MonitoringEvent struct {
+	Level byte
+}
+
+type NoiseCancelModeEvent struct {
+	Mode command.NoiseCancelMode
+}
+
+type TestModeEvent struct {
+	Enabled bool
+}
+
+type SleepModeEvent struct {
+	Enabled bool
+}
+
+type EarTipFitEvent struct {
+	EarTipFitResult
+}
+
+type LEDModeEvent struct {
+	Enabled bool
+}
+
+type PowerManagerEvent struct {
+	PowerManager
+}
+
+type SoundBalanceEvent struct {
+	Value byte
+}
+
+type ANCSettingEvent struct {
+	ANCSetting
+}
+
+type RenameEvent struct {
+	Name string
+}
+
+type AudioLangEvent struct {
+	Lang string
+}
+
+type ToneVolumeEvent struct {
+	ToneVolume
+}
+
+type TakePhotoEvent struct {
+	Action byte
+}
+
+type StandbyEvent struct {
+	Enabled bool
+}
+
+type EQV1Event struct {
+	EQParams
+}
+
+type EQV2Event struct {
+	EQParams
+}
+
+type LDACEvent struct {
+	Enabled bool
+}
+
+type DualConnectionEvent struct {
+	DualConnection
+}
+
+type AdaptiveEQEvent struct {
+	Enabled bool
+}
+
+type ANCResultEvent struct {
+	Data []byte
+}
+
+type ANCWearEvent struct {
+	Data []byte
+}
+
+type KeyFunctionEvent struct {
+	Mappings []KeyMapping
+}
+
+type WearingDetectionEvent struct {
+	WearingDetection
+}
+
+type SpatialAudioEvent struct {
+	Enabled bool
+}
+
+type MusicModeEvent struct {
+	Mode byte
+}
+
+type BatteryEvent struct {
+	Battery
+}
+
+type VersionEvent struct {
+	Version
+}
+
+type EnvAdaptationEvent struct {
+	Enabled bool
+}
+
+type TWSEnableEvent struct {
+	Enabled bool
+}
+
+type LEDSwitchEvent struct {
+	Enabled bool
+}
+
+type LEDEffectEvent struct {
+	LEDEffect
+}
+
+type PlayModeEvent struct {
+	Mode command.PlayMode
+}
+
+type FocusModeEvent struct {
+	Enabled bool
+}
+
+type MusicStatusEvent struct {
+	MusicStatus
+}
+
+type MusicInfoEvent struct {
+	MusicInfoResult
+}
+
+type TonePlayEvent struct {
+	ToneID byte
+}
+
+type SyncTimeEvent struct {
+	SyncTime
+}
+
+type AlarmEvent struct {
+	Alarms []Alarm
+}
+
+type AIEvent struct {
+	Action byte
+}
+
+type MaxEQCountEvent struct {
+	Count byte
+}
+
+type CustomEQTestEvent struct {
+	State byte
+}
+
+type EQLeftEvent struct {
+	EQParams
+}
+
+type EQRightEvent struct {
+	EQParams
+}
+
+type InEarSensitivityEvent struct {
+	Level byte
+}
+
+type GameConfigEvent struct {
+	Config byte
+}
+
+func (ResetDefaultEvent) EventType() EventType     { return EventResetDefault }
+func (ClearPairingEvent) EventType() EventType     { return EventClearPairing }
+func (FactoryResetEvent) EventType() EventType     { return EventFactoryReset }
+func (MusicControlEvent) EventType() EventType     { return EventMusicControl }
+func (LightFlashEvent) EventType() EventType       { return EventLightFlash }
+func (InEarTestEvent) EventType() EventType        { return EventInEarTest }
+func (NoiseValueEvent) EventType() EventType       { return EventNoiseValue }
+func (VolumeEvent) EventType() EventType           { return EventVolume }
+func (LowLatencyEvent) EventType() EventType       { return EventLowLatency }
+func (MonitoringEvent) EventType() EventType       { return EventMonitoring }
+func (NoiseCancelModeEvent) EventType() EventType  { return EventNoiseCancelMode }
+func (TestModeEvent) EventType() EventType         { return EventTestMode }
+func (SleepModeEvent) EventType() EventType        { return EventSleepMode }
+func (EarTipFitEvent) EventType() EventType        { return EventEarTipFit }
+func (LEDModeEvent) EventType() EventType          { return EventLEDMode }
+func (PowerManagerEvent) EventType() EventType     { return EventPowerManager }
+func (SoundBalanceEvent) EventType() EventType     { return EventSoundBalance }
+func (ANCSettingEvent) EventType() EventType       { return EventANCSetting }
+func (RenameEvent) EventType() EventType           { return EventRename }
+func (AudioLangEvent) EventType() EventType        { return EventAudioLang }
+func (ToneVolumeEvent) EventType() EventType       { return EventToneVolume }
+func (TakePhotoEvent) EventType() EventType        { return EventTakePhoto }
+func (StandbyEvent) EventType() EventType          { return EventStandby }
+func (EQV1Event) EventType() EventType             { return EventEQV1 }
+func (EQV2Event) EventType() EventType             { return EventEQV2 }
+func (LDACEvent) EventType() EventType             { return EventLDAC }
+func (DualConnectionEvent) EventType() EventType   { return EventDualConnection }
+func (AdaptiveEQEvent) EventType() EventType       { return EventAdaptiveEQ }
+func (ANCResultEvent) EventType() EventType        { return EventANCResult }
+func (ANCWearEvent) EventType() EventType          { return EventANCWear }
+func (KeyFunctionEvent) EventType() EventType      { return EventKeyFunction }
+func (WearingDetectionEvent) EventType() EventType { return EventWearingDetection }
+func (SpatialAudioEvent) EventType() EventType     { return EventSpatialAudio }
+func (MusicModeEvent) EventType() EventType        { return EventMusicMode }
+func (BatteryEvent) EventType() EventType          { return EventBattery }
+func (VersionEvent) EventType() EventType          { return EventVersion }
+func (EnvAdaptationEvent) EventType() EventType    { return EventEnvAdaptation }
+func (TWSEnableEvent) EventType() EventType        { return EventTWSEnable }
+func (LEDSwitchEvent) EventType() EventType        { return EventLEDSwitch }
+func (LEDEffectEvent) EventType() EventType        { return EventLEDEffect }
+func (PlayModeEvent) EventType() EventType         { return EventPlayMode }
+func (FocusModeEvent) EventType() EventType        { return EventFocusMode }
+func (MusicStatusEvent) EventType() EventType      { return EventMusicStatus }
+func (MusicInfoEvent) EventType() EventType        { return EventMusicInfo }
+func (TonePlayEvent) EventType() EventType         { return EventTonePlay }
+func (SyncTimeEvent) EventType() EventType         { return EventSyncTime }
+func (AlarmEvent) EventType() EventType            { return EventAlarm }
+func (AIEvent) EventType() EventType               { return EventAI }
+func (MaxEQCountEvent) EventType() EventType       { return EventMaxEQCount }
+func (CustomEQTestEvent) EventType() EventType     { return EventCustomEQTest }
+func (EQLeftEvent) EventType() EventType           { return EventEQLeft }
+func (EQRightEvent) EventType() EventType          { return EventEQRight }
+func (InEarSensitivityEvent) EventType() EventType { return EventInEarSensitivity }
+func (GameConfigEvent) EventType() EventType       { return EventGameConfig }
+
+// Typed returns the typed payload of ev, an UnknownEvent for notifications
+// without one, or nil if the payload failed to parse.
+func Typed(ev Event) TypedEvent {
+	if ev.Error != nil {
+		return nil
+	}
+	f, ok := payloads[ev.Type]
+	if !ok {
+		return UnknownEvent{CmdID: ev.CmdID, Raw: ev.Raw}
+	}
+	return f(ev.Parsed)
+}
+
+// payloads wraps the Parsed value of each event type set by Dispatch.
+var payloads = map[EventType]func(any) TypedEvent{
+	EventResetDefault:     ack(ResetDefaultEvent{}),
+	EventClearPairing:     ack(ClearPairingEvent{}),
+	EventFactoryReset:     ack(FactoryResetEvent{}),
+	EventMusicControl:     from(func(b byte) TypedEvent { return MusicControlEvent{Action: b} }),
+	EventLightFlash:       toggle(func(on bool) TypedEvent { return LightFlashEvent{On: on} }),
+	EventInEarTest:        toggle(func(on bool) TypedEvent { return InEarTestEvent{Enabled: on} }),
+	EventNoiseValue:       from(func(b byte) TypedEvent { return NoiseValueEvent{Value: b} }),
+	EventVolume:           from(func(v Volume) TypedEvent { return VolumeEvent{v} }),
+	EventLowLatency:       toggle(func(on bool) TypedEvent { return LowLatencyEvent{Enabled: on} }),
+	EventMonitoring:       from(func(b byte) TypedEvent { return MonitoringEvent{Level: b} }),
+	EventNoiseCancelMode:  from(func(b byte) TypedEvent { return NoiseCancelModeEvent{Mode: command.NoiseCancelMode(b)} }),
+	EventTestMode:         toggle(func(on bool) TypedEvent { return TestModeEvent{Enabled: on} }),
+	EventSleepMode:        toggle(func(on bool) TypedEvent { return SleepModeEvent{Enabled: on} }),
+	EventEarTipFit:        from(func(v EarTipFitResult) TypedEvent { return EarTipFitEvent{v} }),
+	EventLEDMode:          toggle(func(on bool) TypedEvent { return LEDModeEvent{Enabled: on} }),
+	EventPowerManager:     from(func(v PowerManager) TypedEvent { return PowerManagerEvent{v} }),
+	EventSoundBalance:     from(func(b byte) TypedEvent { return SoundBalanceEvent{Value: b} }),
+	EventANCSetting:       from(func(v ANCSetting) TypedEvent { return ANCSettingEvent{v} }),
+	EventRename:           from(func(v string) TypedEvent { return RenameEvent{Name: v} }),
+	EventAudioLang:        from(func(v string) TypedEvent { return AudioLangEvent{Lang: v} }),
+	EventToneVolume:       from(func(v ToneVolume) TypedEvent { return ToneVolumeEvent{v} }),
+	EventTakePhoto:        from(func(b byte) TypedEvent { return TakePhotoEvent{Action: b} }),
+	EventStandby:          toggle(func(on bool) TypedEvent { return StandbyEvent{Enabled: on} }),
+	EventEQV1:             from(func(v EQParams) TypedEvent { return EQV1Event{v} }),
+	EventEQV2:             from(func(v EQParams) TypedEvent { return EQV2Event{v} }),
+	EventLDAC:             toggle(func(on bool) TypedEvent { return LDACEvent{Enabled: on} }),
+	EventDualConnection:   from(func(v DualConnection) TypedEvent { return DualConnectionEvent{v} }),
+	EventAdaptiveEQ:       toggle(func(on bool) TypedEvent { return AdaptiveEQEvent{Enabled: on} }),
+	EventANCResult:        from(func(v []byte) TypedEvent { return ANCResultEvent{Data: v} }),
+	EventANCWear:          from(func(v []byte) TypedEvent { return ANCWearEvent{Data: v} }),
+	EventKeyFunction:      from(func(v []KeyMapping) TypedEvent { return KeyFunctionEvent{Mappings: v} }),
+	EventWearingDetection: from(func(v WearingDetection) TypedEvent { return WearingDetectionEvent{v} }),
+	EventSpatialAudio:     toggle(func(on bool) TypedEvent { return SpatialAudioEvent{Enabled: on} }),
+	EventMusicMode:        from(func(b byte) TypedEvent { return MusicModeEvent{Mode: b} }),
+	EventBattery:          from(func(v Battery) TypedEvent { return BatteryEvent{v} }),
+	EventVersion:          from(func(v Version) TypedEvent { return VersionEvent{v} }),
+	EventEnvAdaptation:    toggle(func(on bool) TypedEvent { return EnvAdaptationEvent{Enabled: on} }),
+	EventTWSEnable:        toggle(func(on bool) TypedEvent { return TWSEnableEvent{Enabled: on} }),
+	EventLEDSwitch:        toggle(func(on bool) TypedEvent { return LEDSwitchEvent{Enabled: on} }),
+	EventLEDEffect:        from(func(v LEDEffect) TypedEvent { return LEDEffectEvent{v} }),
+	EventPlayMode:         from(func(b byte) TypedEvent { return PlayModeEvent{Mode: command.PlayMode(b)} }),
+	EventFocusMode:        toggle(func(on bool) TypedEvent { return FocusModeEvent{Enabled: on} }),
+	EventMusicStatus:      from(func(v MusicStatus) TypedEvent { return MusicStatusEvent{v} }),
+	EventMusicInfo:        from(func(v MusicInfoResult) TypedEvent { return MusicInfoEvent{v} }),
+	EventTonePlay:         from(func(b byte) TypedEvent { return TonePlayEvent{ToneID: b} }),
+	EventSyncTime:         from(func(v SyncTime) TypedEvent { return SyncTimeEvent{v} }),
+	EventAlarm:            from(func(v []Alarm) TypedEvent { return AlarmEvent{Alarms: v} }),
+	EventAI:               from(func(b byte) TypedEvent { return AIEvent{Action: b} }),
+	EventMaxEQCount:       from(func(b byte) TypedEvent { return MaxEQCountEvent{Count: b} }),
+	EventCustomEQTest:     from(func(b byte) TypedEvent { return CustomEQTestEvent{State: b} }),
+	EventEQLeft:           from(func(v EQParams) TypedEvent { return EQLeftEvent{v} }),
+	EventEQRight:          from(func(v EQParams) TypedEvent { return EQRightEvent{v} }),
+	EventInEarSensitivity: from(func(b byte) TypedEvent { return InEarSensitivityEvent{Level: b} }),
+	EventGameConfig:       from(func(b byte) TypedEvent { return GameConfigEvent{Config: b} }),
+}
+
+// from adapts a constructor taking the Parsed value of type P. A Parsed
+// value of another type, such as nil for an empty ack, gives nil.
+func from[P any](f func(P) TypedEvent) func(any) TypedEvent {
+	return func(v any) TypedEvent {
+		p, ok := v.(P)
+		if !ok {
+			return nil
+		}
+		return f(p)
+	}
+}
+
+// toggle adapts a constructor for a 0x01 = on state byte.
+func toggle(f func(bool) TypedEvent) func(any) TypedEvent {
+	return from(func(b byte) TypedEvent { return f(b == 0x01) })
+}
+
+// ack is for acknowledgements, whose payload carries no value.
+func ack(e TypedEvent) func(any) TypedEvent {
+	return func(any) TypedEvent { return e }
+}
@@ -0,0 +1,57 @@
+package response
+
+import (
+	"strings"
+	"time"
+)
+
+// Weekdays is the alarm cycle bitmask: bit 0 = Sunday ... bit 6 = Saturday.
+// An empty set means the alarm fires once.
+type Weekdays byte
+
+const (
+	Sunday Weekdays = 1 << iota
+	Monday
+	Tuesday
+	Wednesday
+	Thursday
+	Friday
+	Saturday
+
+	WorkDays Weekdays = Monday | Tuesday | Wednesday | Thursday | Friday
+	Weekend  Weekdays = Saturday | Sunday
+	EveryDay Weekdays = WorkDays | Weekend
+)
+
+// WeekdaysOf returns the bitmask containing the given days.
+func WeekdaysOf(days ...time.Weekday) Weekdays {
+	var w Weekdays
+	for _, d := range days {
+		w |= 1 << d
+	}
+	return w
+}
+
+func (w Weekdays) Has(d time.Weekday) bool {
+	return w&(1<<d) != 0
+}
+
+func (w Weekdays) String() string {
+	switch w & EveryDay {
+	case 0:
+		return "once"
+	case EveryDay:
+		return "every day"
+	case WorkDays:
+		return "weekdays"
+	case Weekend:
+		return "weekend"
+	}
+	var days []string
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if w.Has(d) {
+			days = append(days, d.String()[:3])
+		}
+	}
+	return strings.Join(days, ",")
+}
@@ -12,11 +12,12 @@ type DeviceType int
 const (
 	DeviceTypeUnknown DeviceType = iota
 	DeviceTypeQCY
+	DeviceTypeQCYWatch
 )
 
 type AdvertisementInfo struct {
 	VendorID        uint16
-	ColorIndex      byte
+	ColorIndex      byte // unnamed: the product list has no colour variants
 	LeftBattery     byte
 	RightBattery    byte
 	BoxBattery      byte
@@ -27,14 +28,25 @@ type AdvertisementInfo struct {
 	OtherMAC        string
 }
 
+// WatchAdvertisement is the manufacturer data of a QCY watch (CompanyID
+// 0x05D6). Only the vendor ID is known to be laid out like the earphone
+// advertisement; the remaining bytes are kept undecoded in Raw.
+type WatchAdvertisement struct {
+	VendorID uint16
+	Raw      []byte
+}
+
 func GetDeviceType(advertise bluetooth.AdvertisementFields) DeviceType {
 	manufacturerData := advertise.ManufacturerData
 	if len(manufacturerData) == 0 {
 		return DeviceTypeUnknown
 	}
 	for _, data := range manufacturerData {
-		if data.CompanyID == constant.QCYCompanyID {
+		switch data.CompanyID {
+		case constant.QCYCompanyID:
 			return DeviceTypeQCY
+		case constant.QCYWatchCompanyID:
+			return DeviceTypeQCYWatch
 		}
 	}
 	return DeviceTypeUnknown
@@ -76,3 +88,15 @@ func ParseManufacturerData(data []byte) (*AdvertisementInfo, error) {
 
 	return info, nil
 }
+
+// ParseWatchManufacturerData parses QCY watch manufacturer data payload.
+// Requires at least 2 bytes for the vendor ID.
+func ParseWatchManufacturerData(data []byte) (*WatchAdvertisement, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("watch manufacturer data too short: got %d bytes, need at least 2", len(data))
+	}
+	return &WatchAdvertisement{
+		VendorID: uint16(data[0])<<8 | uint16(data[1]),
+		Raw:      append([]byte(nil), data...),
+	}, nil
+}
@@ -0,0 +1,201 @@
+package quicky
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/response"
+)
+
+type Weekdays = response.Weekdays
+
+const (
+	Sunday    = response.Sunday
+	Monday    = response.Monday
+	Tuesday   = response.Tuesday
+	Wednesday = response.Wednesday
+	Thursday  = response.Thursday
+	Friday    = response.Friday
+	Saturday  = response.Saturday
+	WorkDays  = response.WorkDays
+	Weekend   = response.Weekend
+	EveryDay  = response.EveryDay
+)
+
+func WeekdaysOf(days ...time.Weekday) Weekdays {
+	return response.WeekdaysOf(days...)
+}
+
+// defaultAlarmIndex is the trailing byte the add command always sends.
+const defaultAlarmIndex byte = 0x05
+
+// AlarmManager keeps the device's alarm list (cmd 0x3F) in line with a desired set.
+type AlarmManager struct {
+	c  *Client
+	mu sync.Mutex
+}
+
+func (c *Client) Alarms() *AlarmManager {
+	return &AlarmManager{c: c}
+}
+
+// List reads the alarms currently stored on the device.
+func (m *AlarmManager) List(ctx context.Context) ([]Alarm, error) {
+	ev, err := m.c.Query(ctx, byte(EventAlarm))
+	if err != nil {
+		return nil, err
+	}
+	alarms, ok := ev.Parsed.([]Alarm)
+	if !ok && ev.Parsed != nil {
+		return nil, fmt.Errorf("alarm list: unexpected payload %T", ev.Parsed)
+	}
+	return alarms, nil
+}
+
+func validateAlarm(a Alarm) error {
+	if a.Hour > 23 || a.Minute > 59 {
+		return fmt.Errorf("alarm %d: invalid time %02d:%02d", a.AlarmID, a.Hour, a.Minute)
+	}
+	if a.Cycle&^EveryDay != 0 {
+		return fmt.Errorf("alarm %d: invalid cycle 0x%02x", a.AlarmID, byte(a.Cycle))
+	}
+	return nil
+}
+
+func sameAlarm(a, b Alarm) bool {
+	return a.Enabled == b.Enabled && a.Hour == b.Hour && a.Minute == b.Minute && a.Cycle == b.Cycle
+}
+
+// AlarmDiff is the set of operations needed to turn one alarm list into another.
+type AlarmDiff struct {
+	Add    []Alarm
+	Edit   []Alarm
+	Delete []Alarm
+}
+
+func (d AlarmDiff) Empty() bool {
+	return len(d.Add) == 0 && len(d.Edit) == 0 && len(d.Delete) == 0
+}
+
+// DiffAlarms computes the operations turning current into desired. Desired
+// alarms with AlarmID 0 are matched to an existing alarm with the same time
+// and cycle, or get a fresh ID, so the result is stable across calls.
+func DiffAlarms(current, desired []Alarm) (AlarmDiff, error) {
+	existing := make(map[byte]Alarm, len(current))
+	for _, a := range current {
+		existing[a.AlarmID] = a
+	}
+
+	claimed := make(map[byte]bool)
+	resolved := make([]Alarm, 0, len(desired))
+	var pending []Alarm
+	for _, a := range desired {
+		if err := validateAlarm(a); err != nil {
+			return AlarmDiff{}, err
+		}
+		if a.AlarmID == 0 {
+			pending = append(pending, a)
+			continue
+		}
+		if claimed[a.AlarmID] {
+			return AlarmDiff{}, fmt.Errorf("alarm %d: duplicate id", a.AlarmID)
+		}
+		claimed[a.AlarmID] = true
+		resolved = append(resolved, a)
+	}
+
+	for _, a := range pending {
+		for _, cur := range current {
+			if !claimed[cur.AlarmID] && cur.Hour == a.Hour && cur.Minute == a.Minute && cur.Cycle == a.Cycle {
+				a.AlarmID = cur.AlarmID
+				break
+			}
+		}
+		if a.AlarmID == 0 {
+			id, err := nextAlarmID(existing, claimed)
+			if err != nil {
+				return AlarmDiff{}, err
+			}
+			a.AlarmID = id
+		}
+		claimed[a.AlarmID] = true
+		resolved = append(resolved, a)
+	}
+
+	var diff AlarmDiff
+	for _, a := range resolved {
+		cur, ok := existing[a.AlarmID]
+		switch {
+		case !ok:
+			a.Index = defaultAlarmIndex
+			diff.Add = append(diff.Add, a)
+		case !sameAlarm(cur, a):
+			a.Index = cur.Index
+			diff.Edit = append(diff.Edit, a)
+		}
+	}
+	for _, cur := range current {
+		if !claimed[cur.AlarmID] {
+			diff.Delete = append(diff.Delete, cur)
+		}
+	}
+	return diff, nil
+}
+
+func nextAlarmID(existing map[byte]Alarm, claimed map[byte]bool) (byte, error) {
+	for id := 1; id <= 0xff; id++ {
+		if _, used := existing[byte(id)]; !used && !claimed[byte(id)] {
+			return byte(id), nil
+		}
+	}
+	return 0, fmt.Errorf("alarm: no free alarm id")
+}
+
+// Sync makes the device's alarm list match desired and returns the applied
+// diff. Calling it again with the same list sends nothing. The device clock
+// is synchronised before any alarm is armed; a sync the device does not
+// confirm is not an error.
+func (m *AlarmManager) Sync(ctx context.Context, desired []Alarm) (AlarmDiff, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, err := m.List(ctx)
+	if err != nil {
+		return AlarmDiff{}, err
+	}
+	diff, err := DiffAlarms(current, desired)
+	if err != nil || diff.Empty() {
+		return diff, err
+	}
+
+	if len(diff.Add) > 0 || len(diff.Edit) > 0 {
+		if _, err := m.c.SyncClock(ctx, time.Local); err != nil {
+			return diff, fmt.Errorf("alarm: sync time: %w", err)
+		}
+	}
+
+	for _, a := range diff.Delete {
+		if err := m.c.DeleteAlarm(a.AlarmID); err != nil {
+			return diff, err
+		}
+	}
+	for _, a := range diff.Add {
+		if err := m.c.AddAlarm(a.AlarmID, a.Hour, a.Minute, byte(a.Cycle)); err != nil {
+			return diff, err
+		}
+		// The add command always arms the alarm.
+		if !a.Enabled {
+			if err := m.c.EditAlarm(a.AlarmID, false, a.Hour, a.Minute, byte(a.Cycle), a.Index); err != nil {
+				return diff, err
+			}
+		}
+	}
+	for _, a := range diff.Edit {
+		if err := m.c.EditAlarm(a.AlarmID, a.Enabled, a.Hour, a.Minute, byte(a.Cycle), a.Index); err != nil {
+			return diff, err
+		}
+	}
+	return diff, nil
+}
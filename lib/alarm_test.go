@@ -0,0 +1,76 @@
+package quicky
+
+import "testing"
+
+func TestDiffAlarms(t *testing.T) {
+	current := []Alarm{
+		{AlarmID: 1, Enabled: true, Hour: 7, Minute: 0, Cycle: WorkDays, Index: 0x05},
+		{AlarmID: 2, Enabled: true, Hour: 9, Minute: 30, Cycle: Weekend, Index: 0x07},
+		{AlarmID: 3, Enabled: true, Hour: 22, Minute: 0, Cycle: EveryDay, Index: 0x05},
+	}
+	desired := []Alarm{
+		// Matched to alarm 1 by time and cycle, unchanged.
+		{Enabled: true, Hour: 7, Minute: 0, Cycle: WorkDays},
+		// Alarm 2 is disabled and keeps its trailing byte.
+		{AlarmID: 2, Enabled: false, Hour: 9, Minute: 30, Cycle: Weekend},
+		// New alarm gets the first free ID.
+		{Enabled: true, Hour: 6, Minute: 15, Cycle: Monday},
+	}
+
+	diff, err := DiffAlarms(current, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Add) != 1 || diff.Add[0].AlarmID != 4 || diff.Add[0].Index != defaultAlarmIndex {
+		t.Errorf("add = %+v", diff.Add)
+	}
+	if len(diff.Edit) != 1 || diff.Edit[0].AlarmID != 2 || diff.Edit[0].Enabled || diff.Edit[0].Index != 0x07 {
+		t.Errorf("edit = %+v", diff.Edit)
+	}
+	if len(diff.Delete) != 1 || diff.Delete[0].AlarmID != 3 {
+		t.Errorf("delete = %+v", diff.Delete)
+	}
+
+	// Applying the same list to the result sends nothing.
+	after := []Alarm{current[0], diff.Edit[0], diff.Add[0]}
+	again, err := DiffAlarms(after, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Empty() {
+		t.Errorf("second diff = %+v, want empty", again)
+	}
+}
+
+func TestDiffAlarmsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired []Alarm
+	}{
+		{"hour", []Alarm{{AlarmID: 1, Hour: 24}}},
+		{"minute", []Alarm{{AlarmID: 1, Minute: 60}}},
+		{"cycle", []Alarm{{AlarmID: 1, Cycle: 0x80}}},
+		{"duplicate", []Alarm{{AlarmID: 1, Hour: 7}, {AlarmID: 1, Hour: 8}}},
+	}
+	for _, tt := range tests {
+		if _, err := DiffAlarms(nil, tt.desired); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestNextAlarmID(t *testing.T) {
+	existing := map[byte]Alarm{1: {AlarmID: 1}, 3: {AlarmID: 3}}
+	claimed := map[byte]bool{2: true}
+	if id, err := nextAlarmID(existing, claimed); err != nil || id != 4 {
+		t.Errorf("nextAlarmID = %d, %v, want 4", id, err)
+	}
+
+	full := make(map[byte]bool)
+	for id := 1; id <= 0xff; id++ {
+		full[byte(id)] = true
+	}
+	if _, err := nextAlarmID(nil, full); err == nil {
+		t.Error("expected an error when every id is taken")
+	}
+}
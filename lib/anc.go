@@ -0,0 +1,146 @@
+package quicky
+
+import (
+	"fmt"
+
+	"github.com/hui1601/Quicky/internal/command"
+)
+
+type ANCScene = command.ANCScene
+type ANCEnvironment = command.ANCEnvironment
+
+const (
+	ANCEnvironmentOff          = command.ANCEnvironmentOff
+	ANCEnvironmentANC          = command.ANCEnvironmentANC
+	ANCEnvironmentNormal       = command.ANCEnvironmentNormal
+	ANCEnvironmentTransparency = command.ANCEnvironmentTransparency
+)
+
+var ANCSceneOff = command.ANCSceneOff
+
+func NewANCScene(env ANCEnvironment, subScene, noiseValue byte) (ANCScene, error) {
+	return command.NewANCScene(env, subScene, noiseValue)
+}
+
+func UnpackANCScene(packed uint32) ANCScene {
+	return command.UnpackANCScene(packed)
+}
+
+// maxSceneSteps is the widest sub-scene range expanded into separate scenes.
+// Wider ranges, such as 0x010100-0x01ffff on the mode entries of many
+// control panels, only bound the values the items may use and become one
+// slider.
+const maxSceneSteps = 16
+
+// ProductANCScene is a selectable ANC scene as listed by a product's control
+// panel. A range whose values differ only in the noise byte, or which spans
+// more than a few sub-scenes, is a slider from Scene to Last.
+type ProductANCScene struct {
+	Mode    string // ANC mode name, e.g. "Noise canceling"
+	Item    string // item name within the mode, empty for modes without items
+	Scene   ANCScene
+	Last    ANCScene // equal to Scene unless the entry is a slider
+	Default bool
+}
+
+// Slider reports whether the entry is a range of scenes rather than one.
+func (s ProductANCScene) Slider() bool {
+	return s.Last != s.Scene
+}
+
+// ProductANCScenes expands the start/end cmdid ranges of a product's ANC modes
+// and items into scenes, one per sub-scene.
+func ProductANCScenes(p *Product) []ProductANCScene {
+	if p == nil || p.Features.ANC == nil {
+		return nil
+	}
+	var scenes []ProductANCScene
+	for _, mode := range p.Features.ANC.Modes {
+		if len(mode.Items) == 0 {
+			scenes = appendSceneRange(scenes, mode.Name, "", mode.StartCmdID, mode.EndCmdID, mode.DefaultCmd)
+			continue
+		}
+		for _, item := range mode.Items {
+			scenes = appendSceneRange(scenes, mode.Name, item.Name, item.StartCmdID, item.EndCmdID, mode.DefaultCmd)
+		}
+	}
+	return scenes
+}
+
+// sceneOf splits a packed value from the product database. Unlike
+// UnpackANCScene it does not remap, as these are values to be sent.
+func sceneOf(v int) ANCScene {
+	return ANCScene{Mode: byte(v >> 16), SubScene: byte(v >> 8), NoiseValue: byte(v)}
+}
+
+func appendSceneRange(scenes []ProductANCScene, mode, item string, start, end, def int) []ProductANCScene {
+	if start < 0 || end < start || end > 0xffffff {
+		return scenes
+	}
+	first, last := start>>8, end>>8 // mode and sub-scene bytes
+	if first == last || last-first > maxSceneSteps {
+		return append(scenes, ProductANCScene{
+			Mode:    mode,
+			Item:    item,
+			Scene:   sceneOf(start),
+			Last:    sceneOf(end),
+			Default: def >= start && def <= end,
+		})
+	}
+	for sub := first; sub <= last; sub++ {
+		v := sub << 8
+		if sub == first {
+			v = start
+		}
+		scene := sceneOf(v)
+		scenes = append(scenes, ProductANCScene{
+			Mode:    mode,
+			Item:    item,
+			Scene:   scene,
+			Last:    scene,
+			Default: def>>8 == sub,
+		})
+	}
+	return scenes
+}
+
+// usesANCSetting reports whether the product's ANC modes are packed scenes
+// (cmd 0x17). The second result is false when the product gives no hint.
+func usesANCSetting(p *Product) (bool, bool) {
+	if p == nil || p.Features.ANC == nil || len(p.Features.ANC.Modes) == 0 {
+		return false, false
+	}
+	for _, mode := range p.Features.ANC.Modes {
+		if mode.StartCmdID > 0xff || mode.EndCmdID > 0xff {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// SetProduct sets the product definition used to pick model-specific encodings.
+func (c *Client) SetProduct(p *Product) {
+	c.product = p
+}
+
+// Product returns the product definition set with SetProduct, or nil.
+func (c *Client) Product() *Product {
+	return c.product
+}
+
+// SetANCScene sends an ANC scene. Models whose control panel uses packed scenes
+// get cmd 0x17; legacy models get the single-byte cmd 0x0C. Without a product,
+// the encoding is chosen from the scene value.
+func (c *Client) SetANCScene(scene ANCScene) error {
+	packed, known := usesANCSetting(c.product)
+	if !known {
+		return c.send(command.NewANCSceneCommand(scene))
+	}
+	if packed {
+		return c.send(command.NewANCSettingCommand(scene.Mode, scene.SubScene, scene.NoiseValue))
+	}
+	if scene.Packed() > 0xff {
+		return fmt.Errorf("anc scene %s: %s only supports single-byte noise modes", scene, c.product.Title)
+	}
+	return c.send(command.NewNoiseCancelModeCommand(NoiseCancelMode(scene.Packed())))
+}
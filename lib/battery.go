@@ -0,0 +1,538 @@
+package quicky
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BatterySide selects one of the three batteries reported by the device.
+type BatterySide int
+
+const (
+	BatteryLeft BatterySide = iota
+	BatteryRight
+	BatteryCase
+)
+
+var batterySides = []BatterySide{BatteryLeft, BatteryRight, BatteryCase}
+
+func (s BatterySide) String() string {
+	switch s {
+	case BatteryLeft:
+		return "left"
+	case BatteryRight:
+		return "right"
+	case BatteryCase:
+		return "case"
+	}
+	return fmt.Sprintf("side(%d)", int(s))
+}
+
+func batteryInfo(b Battery, s BatterySide) BatteryInfo {
+	switch s {
+	case BatteryLeft:
+		return b.Left
+	case BatteryRight:
+		return b.Right
+	}
+	return b.Case
+}
+
+// BatterySample is a battery reading at a point in time.
+type BatterySample struct {
+	Time    time.Time `json:"time"`
+	Device  string    `json:"device,omitempty"`
+	Battery Battery   `json:"battery"`
+}
+
+// BatteryAlert is raised when a battery drops to or below a threshold.
+type BatteryAlert struct {
+	Side      BatterySide
+	Level     byte
+	Threshold byte
+	Time      time.Time
+}
+
+// ChargingChange is raised when a battery starts or stops charging.
+type ChargingChange struct {
+	Side     BatterySide
+	Charging bool
+	Time     time.Time
+}
+
+type BatteryMonitorOptions struct {
+	// PollInterval reads the battery characteristic periodically. Zero only
+	// listens for 0x2F notifications.
+	PollInterval time.Duration
+	// Thresholds are the alert levels in percent. Defaults to 20 and 10.
+	Thresholds []byte
+	// HistorySize caps the in-memory series. Defaults to 2048 samples.
+	HistorySize int
+	// EstimateWindow is how far back the discharge slope looks. Defaults to 30 minutes.
+	EstimateWindow time.Duration
+	// Device identifies the headset in persisted samples, e.g. the control MAC
+	// or serial number. Defaults to the client address.
+	Device string
+	// StoragePath, if set, is a JSON lines file that samples are loaded from
+	// and appended to.
+	StoragePath string
+	// StorageLimit caps the samples kept in the file, across all devices. The
+	// file is compacted to the newest StorageLimit samples when it opens and
+	// whenever it grows to twice the limit. Defaults to 100000.
+	StorageLimit int
+
+	OnSample   func(BatterySample)
+	OnAlert    func(BatteryAlert)
+	OnCharging func(ChargingChange)
+	// OnError receives errors writing to the storage file during Run.
+	OnError func(error)
+}
+
+// BatteryMonitor keeps a time series of battery readings and raises alerts.
+type BatteryMonitor struct {
+	c    *Client
+	opts BatteryMonitorOptions
+
+	mu      sync.Mutex
+	history []BatterySample
+	last    *BatterySample // last sample recorded since the monitor was created
+	alerted [3]map[byte]bool
+	health  [3]BatteryHealth
+	store   *os.File
+	stored  int // samples in the storage file
+}
+
+func NewBatteryMonitor(c *Client, opts BatteryMonitorOptions) *BatteryMonitor {
+	if opts.Thresholds == nil {
+		opts.Thresholds = []byte{20, 10}
+	}
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = 2048
+	}
+	if opts.EstimateWindow <= 0 {
+		opts.EstimateWindow = 30 * time.Minute
+	}
+	if opts.StorageLimit <= 0 {
+		opts.StorageLimit = 100000
+	}
+	if opts.Device == "" && c != nil {
+		opts.Device = c.dev.MAC.String()
+	}
+	m := &BatteryMonitor{c: c, opts: opts}
+	for i := range m.alerted {
+		m.alerted[i] = make(map[byte]bool)
+	}
+	return m
+}
+
+// Run records samples until ctx is done.
+func (m *BatteryMonitor) Run(ctx context.Context) error {
+	if m.c == nil {
+		return errors.New("battery monitor: no client")
+	}
+	if err := m.open(); err != nil {
+		return err
+	}
+	defer m.close()
+
+	stop := On(m.c, func(ev BatteryEvent) {
+		m.report(m.Record(ev.Battery, time.Now()))
+	})
+	defer stop()
+
+	var tick <-chan time.Time
+	if m.opts.PollInterval > 0 {
+		ticker := time.NewTicker(m.opts.PollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+		m.poll()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick:
+			m.poll()
+		}
+	}
+}
+
+func (m *BatteryMonitor) poll() {
+	if !m.c.Connected() {
+		return
+	}
+	if b, err := m.c.ReadBattery(); err == nil {
+		m.report(m.Record(b, time.Now()))
+	}
+}
+
+func (m *BatteryMonitor) report(err error) {
+	if err != nil && m.opts.OnError != nil {
+		m.opts.OnError(err)
+	}
+}
+
+// open loads previously stored samples for this device, compacts the file
+// if needed and opens it for appending.
+func (m *BatteryMonitor) open() error {
+	if m.opts.StoragePath == "" {
+		return nil
+	}
+	all, err := m.load()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stored = len(all)
+	if m.stored > m.opts.StorageLimit {
+		return m.compact(all)
+	}
+	return m.openStore()
+}
+
+func (m *BatteryMonitor) openStore() error {
+	f, err := os.OpenFile(m.opts.StoragePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	m.store = f
+	return nil
+}
+
+// load reads every stored sample and adds this device's samples to the
+// history and health statistics. It returns all samples, oldest first.
+func (m *BatteryMonitor) load() ([]BatterySample, error) {
+	all, err := readBatterySamples(m.opts.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	var samples []BatterySample
+	for _, s := range all {
+		if s.Device == m.opts.Device {
+			samples = append(samples, s)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 1; i < len(samples); i++ {
+		m.addHealth(samples[i-1], samples[i])
+	}
+	m.history = append(samples, m.history...)
+	m.trim()
+	return all, nil
+}
+
+func readBatterySamples(path string) ([]BatterySample, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []BatterySample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s BatterySample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("battery monitor: %s: %w", path, err)
+		}
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	return samples, nil
+}
+
+// compact rewrites the storage file with the newest StorageLimit of all and
+// reopens it for appending. m.mu must be held.
+func (m *BatteryMonitor) compact(all []BatterySample) error {
+	if m.store != nil {
+		m.store.Close()
+		m.store = nil
+	}
+	if over := len(all) - m.opts.StorageLimit; over > 0 {
+		all = all[over:]
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.opts.StoragePath), filepath.Base(m.opts.StoragePath)+".*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, s := range all {
+		if err = enc.Encode(s); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), m.opts.StoragePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("battery monitor: compact %s: %w", m.opts.StoragePath, err)
+	}
+	m.stored = len(all)
+	return m.openStore()
+}
+
+// persist appends a sample to the storage file, compacting it when it has
+// grown to twice the limit. m.mu must be held.
+func (m *BatteryMonitor) persist(sample BatterySample) error {
+	if m.store == nil {
+		return nil
+	}
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	if _, err := m.store.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("battery monitor: %s: %w", m.opts.StoragePath, err)
+	}
+	m.stored++
+	if m.stored < 2*m.opts.StorageLimit {
+		return nil
+	}
+	all, err := readBatterySamples(m.opts.StoragePath)
+	if err != nil {
+		return err
+	}
+	return m.compact(all)
+}
+
+func (m *BatteryMonitor) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store != nil {
+		m.store.Close()
+		m.store = nil
+	}
+}
+
+func (m *BatteryMonitor) trim() {
+	if over := len(m.history) - m.opts.HistorySize; over > 0 {
+		m.history = append([]BatterySample(nil), m.history[over:]...)
+	}
+}
+
+// Record adds a reading taken at t, raising alerts and charging changes as
+// needed. Charging changes are only detected between samples recorded by this
+// monitor, not against samples loaded from storage. The sample is kept even
+// if writing it to the storage file fails; the error is returned.
+func (m *BatteryMonitor) Record(b Battery, t time.Time) error {
+	sample := BatterySample{Time: t, Device: m.opts.Device, Battery: b}
+
+	m.mu.Lock()
+	if n := len(m.history); n > 0 {
+		m.addHealth(m.history[n-1], sample)
+	}
+	prev := m.last
+	m.last = &sample
+	m.history = append(m.history, sample)
+	m.trim()
+
+	var alerts []BatteryAlert
+	var changes []ChargingChange
+	for _, side := range batterySides {
+		info := batteryInfo(b, side)
+		if prev != nil && batteryInfo(prev.Battery, side).Charging != info.Charging {
+			changes = append(changes, ChargingChange{Side: side, Charging: info.Charging, Time: t})
+		}
+		alerts = append(alerts, m.checkThresholds(side, info, t)...)
+	}
+	err := m.persist(sample)
+	m.mu.Unlock()
+
+	if m.opts.OnSample != nil {
+		m.opts.OnSample(sample)
+	}
+	if m.opts.OnCharging != nil {
+		for _, ch := range changes {
+			m.opts.OnCharging(ch)
+		}
+	}
+	if m.opts.OnAlert != nil {
+		for _, a := range alerts {
+			m.opts.OnAlert(a)
+		}
+	}
+	return err
+}
+
+// checkThresholds raises each threshold once per discharge; charging or
+// rising above the threshold re-arms it. A level of 0 is treated as
+// "not reported" (e.g. the case when the buds are out).
+func (m *BatteryMonitor) checkThresholds(side BatterySide, info BatteryInfo, t time.Time) []BatteryAlert {
+	var alerts []BatteryAlert
+	for _, th := range m.opts.Thresholds {
+		switch {
+		case info.Charging || info.Level > th:
+			delete(m.alerted[side], th)
+		case info.Level == 0:
+		case !m.alerted[side][th]:
+			m.alerted[side][th] = true
+			alerts = append(alerts, BatteryAlert{Side: side, Level: info.Level, Threshold: th, Time: t})
+		}
+	}
+	return alerts
+}
+
+// History returns a copy of the recorded samples, oldest first.
+func (m *BatteryMonitor) History() []BatterySample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]BatterySample(nil), m.history...)
+}
+
+// Latest returns the most recent sample.
+func (m *BatteryMonitor) Latest() (BatterySample, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.history) == 0 {
+		return BatterySample{}, false
+	}
+	return m.history[len(m.history)-1], true
+}
+
+// DischargeRate returns the discharge rate of a side in percent per hour,
+// fitted over the samples in the estimate window since it last charged.
+func (m *BatteryMonitor) DischargeRate(side BatterySide) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dischargeRate(side)
+}
+
+func (m *BatteryMonitor) dischargeRate(side BatterySide) (float64, bool) {
+	if len(m.history) < 2 {
+		return 0, false
+	}
+	last := m.history[len(m.history)-1]
+	since := last.Time.Add(-m.opts.EstimateWindow)
+
+	var xs, ys []float64
+	for i := len(m.history) - 1; i >= 0; i-- {
+		s := m.history[i]
+		info := batteryInfo(s.Battery, side)
+		if s.Time.Before(since) || info.Charging || info.Level == 0 {
+			break
+		}
+		xs = append(xs, s.Time.Sub(since).Hours())
+		ys = append(ys, float64(info.Level))
+	}
+	if len(xs) < 2 {
+		return 0, false
+	}
+	slope, ok := linearSlope(xs, ys)
+	if !ok || slope >= 0 {
+		return 0, false
+	}
+	return -slope, true
+}
+
+// EstimateRemaining estimates how long a side lasts at its current discharge rate.
+func (m *BatteryMonitor) EstimateRemaining(side BatterySide) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rate, ok := m.dischargeRate(side)
+	if !ok {
+		return 0, false
+	}
+	level := batteryInfo(m.history[len(m.history)-1].Battery, side).Level
+	return time.Duration(float64(level) / rate * float64(time.Hour)), true
+}
+
+// healthMaxGap is the longest gap between two samples that still counts as
+// continuous use for the health statistics.
+const healthMaxGap = time.Hour
+
+// BatteryHealth holds long-term statistics of one battery over every sample
+// recorded or loaded from storage for the device.
+type BatteryHealth struct {
+	Since      time.Time     // time of the first sample
+	Discharged int           // total percentage points discharged
+	InUse      time.Duration // time spent discharging
+	Charges    int           // times charging started
+}
+
+// Cycles returns the discharge in equivalent full cycles.
+func (h BatteryHealth) Cycles() float64 {
+	return float64(h.Discharged) / 100
+}
+
+// Rate returns the average discharge rate in percent per hour.
+func (h BatteryHealth) Rate() (float64, bool) {
+	if h.InUse <= 0 {
+		return 0, false
+	}
+	return float64(h.Discharged) / h.InUse.Hours(), true
+}
+
+// addHealth updates the statistics with the step from prev to cur. m.mu must
+// be held.
+func (m *BatteryMonitor) addHealth(prev, cur BatterySample) {
+	gap := cur.Time.Sub(prev.Time)
+	for _, side := range batterySides {
+		h := &m.health[side]
+		if h.Since.IsZero() {
+			h.Since = prev.Time
+		}
+		a, b := batteryInfo(prev.Battery, side), batteryInfo(cur.Battery, side)
+		if b.Charging && !a.Charging {
+			h.Charges++
+		}
+		if gap <= 0 || gap > healthMaxGap || a.Charging || b.Charging || a.Level == 0 || b.Level == 0 {
+			continue
+		}
+		if b.Level < a.Level {
+			h.Discharged += int(a.Level - b.Level)
+		}
+		h.InUse += gap
+	}
+}
+
+// Health returns the long-term statistics of a side.
+func (m *BatteryMonitor) Health(side BatterySide) BatteryHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if side < BatteryLeft || side > BatteryCase {
+		return BatteryHealth{}
+	}
+	return m.health[side]
+}
+
+// linearSlope returns the least-squares slope of ys over xs.
+func linearSlope(xs, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	d := n*sxx - sx*sx
+	if d == 0 {
+		return 0, false
+	}
+	return (n*sxy - sx*sy) / d, true
+}
@@ -0,0 +1,96 @@
+package quicky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/command"
+	"github.com/hui1601/Quicky/internal/response"
+)
+
+type SyncTime = response.SyncTime
+
+// clockConfirmTimeout bounds how long SyncClock waits for the 0x3E echo.
+const clockConfirmTimeout = 3 * time.Second
+
+// ClockSync describes the outcome of a clock synchronisation.
+type ClockSync struct {
+	Sent      time.Time
+	Confirmed bool          // the device echoed the time via 0x3E
+	Drift     time.Duration // device time minus sent time, valid if Confirmed
+	V1        bool          // written to the V1 time characteristic (0x000C)
+}
+
+// SyncClock sets the device clock to the current time in loc (time.Local if
+// nil) and waits for the 0x3E notification to confirm it. Older models that
+// never answer but expose the V1 time characteristic are written there
+// instead. A device that does neither is not an error; the result is then
+// left unconfirmed.
+func (c *Client) SyncClock(ctx context.Context, loc *time.Location) (ClockSync, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	now := time.Now().In(loc)
+	if now.Year() < 2000 || now.Year() > 2099 {
+		return ClockSync{}, fmt.Errorf("sync clock: year %d cannot be sent as 2 digits", now.Year())
+	}
+	result := ClockSync{Sent: now}
+
+	waitCtx, cancel := context.WithTimeout(ctx, clockConfirmTimeout)
+	defer cancel()
+	ev, err := c.sendAndWait(waitCtx, command.NewSyncTimeCommand(now), byte(EventSyncTime))
+	switch {
+	case err == nil:
+		st, ok := ev.Parsed.(SyncTime)
+		if !ok {
+			return result, fmt.Errorf("sync clock: unexpected payload %T", ev.Parsed)
+		}
+		result.Confirmed = true
+		result.Drift = st.Time(loc).Sub(now.Truncate(time.Second))
+		return result, nil
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		if !c.dev.HasTimeCharacteristic() {
+			return result, nil
+		}
+		result.V1 = true
+		return result, c.dev.WriteTime(command.NewSyncTimeData(now))
+	}
+	return result, err
+}
+
+// KeepClockSynced synchronises the clock now, every interval (if positive) and
+// after every reconnect, until ctx is done. Failed attempts are reported to
+// onError, if set, and retried at the next trigger.
+func (c *Client) KeepClockSynced(ctx context.Context, loc *time.Location, interval time.Duration, onError func(error)) error {
+	reconnected := make(chan struct{}, 1)
+	remove := c.onConnect(func() {
+		select {
+		case reconnected <- struct{}{}:
+		default:
+		}
+	})
+	defer remove()
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if c.Connected() {
+			if _, err := c.SyncClock(ctx, loc); err != nil && ctx.Err() == nil && onError != nil {
+				onError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick:
+		case <-reconnected:
+		}
+	}
+}
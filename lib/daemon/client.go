@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// RPCClient talks to a running daemon. Calls are sequential; notifications
+// arriving while a call waits are queued for NextEvent.
+type RPCClient struct {
+	conn net.Conn
+	dec  *json.Decoder
+	enc  *json.Encoder
+
+	mu     sync.Mutex
+	nextID int
+	queue  []Event
+}
+
+// Dial connects to the daemon socket at path.
+func Dial(path string) (*RPCClient, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCClient{conn: conn, dec: json.NewDecoder(conn), enc: json.NewEncoder(conn)}, nil
+}
+
+func (c *RPCClient) Close() error {
+	return c.conn.Close()
+}
+
+type rpcReply struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// Call invokes method and decodes its result into result, which may be nil.
+func (c *RPCClient) Call(method string, params, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	req := struct {
+		JSONRPC string `json:"jsonrpc"`
+		ID      int    `json:"id"`
+		Method  string `json:"method"`
+		Params  any    `json:"params,omitempty"`
+	}{"2.0", c.nextID, method, params}
+	if err := c.enc.Encode(req); err != nil {
+		return err
+	}
+	for {
+		reply, err := c.read()
+		if err != nil {
+			return err
+		}
+		if string(reply.ID) != id {
+			continue
+		}
+		if reply.Error != nil {
+			return reply.Error
+		}
+		if result == nil || len(reply.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(reply.Result, result)
+	}
+}
+
+// NextEvent returns the next event after a subscribe-events call, blocking
+// until one arrives.
+func (c *RPCClient) NextEvent() (Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.queue) > 0 {
+		ev := c.queue[0]
+		c.queue = c.queue[1:]
+		return ev, nil
+	}
+	for {
+		if _, err := c.read(); err != nil {
+			return Event{}, err
+		}
+		if len(c.queue) > 0 {
+			ev := c.queue[0]
+			c.queue = c.queue[1:]
+			return ev, nil
+		}
+	}
+}
+
+// read decodes one message, queueing it if it is an event.
+func (c *RPCClient) read() (rpcReply, error) {
+	var reply rpcReply
+	if err := c.dec.Decode(&reply); err != nil {
+		return rpcReply{}, err
+	}
+	if reply.Method == "event" {
+		var ev Event
+		if err := json.Unmarshal(reply.Params, &ev); err != nil {
+			return rpcReply{}, fmt.Errorf("invalid event: %w", err)
+		}
+		c.queue = append(c.queue, ev)
+	}
+	return reply, nil
+}
@@ -0,0 +1,159 @@
+// Package daemon keeps long-lived connections to configured headsets, caches
+// their state and serves it to several front-ends over a local socket.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HeadsetConfig is one headset the daemon keeps connected.
+type HeadsetConfig struct {
+	// Name identifies the headset in requests, e.g. "work buds".
+	Name string `json:"name"`
+	// Address is any of the headset's MACs; it is resolved to the control MAC on connect.
+	Address string `json:"address"`
+	// Profile is applied after every connect, if set.
+	Profile string `json:"profile,omitempty"`
+}
+
+// Profile is a named set of settings applied together.
+type Profile struct {
+	// ANC is a scene such as "off", "anc/2" or "transparency/1/4".
+	ANC string `json:"anc,omitempty"`
+	// Settings maps product setting names (or opcodes such as "0x24") to
+	// values, as accepted by Client.SetSetting.
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type Config struct {
+	// Socket is the path of the JSON-RPC Unix socket. Defaults to
+	// $XDG_RUNTIME_DIR/quicky.sock.
+	Socket string `json:"socket,omitempty"`
+	// DBus also publishes the headsets on the session bus as DBusName.
+	DBus bool `json:"dbus,omitempty"`
+	// MPRIS forwards earbud music gestures to the desktop player and
+	// publishes on-device music libraries as MPRIS2 players.
+	MPRIS bool `json:"mpris,omitempty"`
+	// HTTP, if set, is the address the REST API listens on, e.g.
+	// "127.0.0.1:8370".
+	HTTP     string             `json:"http,omitempty"`
+	Headsets []HeadsetConfig    `json:"headsets"`
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	// HealthInterval is how often a connection is checked by reading the
+	// battery. Defaults to 30 seconds.
+	HealthInterval Duration `json:"healthInterval,omitempty"`
+	// ReconnectMin and ReconnectMax bound the reconnect backoff. They
+	// default to 2 seconds and 2 minutes.
+	ReconnectMin Duration `json:"reconnectMin,omitempty"`
+	ReconnectMax Duration `json:"reconnectMax,omitempty"`
+}
+
+func (c *Config) setDefaults() {
+	if c.Socket == "" {
+		c.Socket = DefaultSocketPath()
+	}
+	if c.HealthInterval <= 0 {
+		c.HealthInterval = Duration(30 * time.Second)
+	}
+	if c.ReconnectMin <= 0 {
+		c.ReconnectMin = Duration(2 * time.Second)
+	}
+	if c.ReconnectMax < c.ReconnectMin {
+		c.ReconnectMax = Duration(2 * time.Minute)
+	}
+}
+
+// Validate checks that headsets have unique names and addresses, that their
+// profiles exist and that profile ANC scenes parse.
+func (c *Config) Validate() error {
+	if len(c.Headsets) == 0 {
+		return errors.New("daemon config: no headsets")
+	}
+	seen := make(map[string]bool)
+	for i, h := range c.Headsets {
+		if h.Address == "" {
+			return fmt.Errorf("daemon config: headset %d: address is required", i)
+		}
+		for _, key := range []string{strings.ToLower(h.Name), strings.ToLower(h.Address)} {
+			if key == "" {
+				continue
+			}
+			if seen[key] {
+				return fmt.Errorf("daemon config: headset %q listed twice", key)
+			}
+			seen[key] = true
+		}
+		if h.Profile != "" {
+			if _, ok := c.Profiles[h.Profile]; !ok {
+				return fmt.Errorf("daemon config: headset %q: unknown profile %q", h.Name, h.Profile)
+			}
+		}
+	}
+	for name, p := range c.Profiles {
+		if p.ANC == "" {
+			continue
+		}
+		if _, err := parseScene(p.ANC); err != nil {
+			return fmt.Errorf("daemon config: profile %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// DefaultConfigPath returns ~/.config/quicky/daemon.json on Linux.
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "quicky", "daemon.json"), nil
+}
+
+// DefaultSocketPath returns $XDG_RUNTIME_DIR/quicky.sock, falling back to the
+// temporary directory.
+func DefaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "quicky.sock")
+}
+
+// LoadConfig reads and validates a config file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	cfg.setDefaults()
+	return cfg, nil
+}
@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		err  string
+	}{
+		{"ok", Config{
+			Headsets: []HeadsetConfig{{Name: "a", Address: "AA:BB:CC:DD:EE:01", Profile: "quiet"}},
+			Profiles: map[string]Profile{"quiet": {ANC: "anc/2"}},
+		}, ""},
+		{"no headsets", Config{}, "no headsets"},
+		{"no address", Config{Headsets: []HeadsetConfig{{Name: "a"}}}, "address is required"},
+		{"duplicate name", Config{Headsets: []HeadsetConfig{
+			{Name: "Buds", Address: "AA:BB:CC:DD:EE:01"},
+			{Name: "buds", Address: "AA:BB:CC:DD:EE:02"},
+		}}, "listed twice"},
+		{"duplicate address", Config{Headsets: []HeadsetConfig{
+			{Address: "AA:BB:CC:DD:EE:01"},
+			{Address: "aa:bb:cc:dd:ee:01"},
+		}}, "listed twice"},
+		{"unknown profile", Config{
+			Headsets: []HeadsetConfig{{Name: "a", Address: "AA:BB:CC:DD:EE:01", Profile: "loud"}},
+		}, "unknown profile"},
+		{"invalid scene", Config{
+			Headsets: []HeadsetConfig{{Address: "AA:BB:CC:DD:EE:01"}},
+			Profiles: map[string]Profile{"quiet": {ANC: "anc/9"}},
+		}, `profile "quiet"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.json")
+	data := `{"headsets": [{"address": "AA:BB:CC:DD:EE:01"}], "healthInterval": "10s"}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(cfg.HealthInterval) != 10*time.Second {
+		t.Errorf("HealthInterval = %v, want 10s", time.Duration(cfg.HealthInterval))
+	}
+	if time.Duration(cfg.ReconnectMin) != 2*time.Second || time.Duration(cfg.ReconnectMax) != 2*time.Minute {
+		t.Errorf("reconnect backoff = %v-%v", time.Duration(cfg.ReconnectMin), time.Duration(cfg.ReconnectMax))
+	}
+	if cfg.Socket == "" {
+		t.Error("Socket not defaulted")
+	}
+}
+
+func TestParseScene(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{"off", "off", false},
+		{"ANC/2", "anc/2", false},
+		{"normal", "normal", false},
+		{"transparency/1/4", "transparency/1/4", false},
+		{"0x010200", "anc/2", false},
+		{"0x030100", "transparency/1", false},
+		{"0x040100", "0x040100", false},
+		{"anc/6", "", true},
+		{"transparency/1/7", "", true},
+		{"normal/1", "", true},
+		{"anc/1/2/3", "", true},
+		{"loud/1", "", true},
+		{"0xzz", "", true},
+	}
+	for _, tt := range tests {
+		s, err := parseScene(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseScene(%q) = %v, want an error", tt.in, s)
+			}
+			continue
+		}
+		if err != nil || s.String() != tt.want {
+			t.Errorf("parseScene(%q) = %v, %v, want %s", tt.in, s, err, tt.want)
+		}
+	}
+}
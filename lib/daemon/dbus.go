@@ -0,0 +1,283 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+// D-Bus names of the session bus service. Each headset is an object below
+// DBusPath implementing DBusHeadsetInterface; DBusPath itself implements
+// DBusManagerInterface.
+const (
+	DBusName             = "io.github.quicky"
+	DBusPath             = dbus.ObjectPath("/io/github/quicky")
+	DBusManagerInterface = "io.github.quicky.Manager1"
+	DBusHeadsetInterface = "io.github.quicky.Headset1"
+
+	dbusErrNotConnected = "io.github.quicky.Error.NotConnected"
+	dbusErrInvalidArgs  = "io.github.quicky.Error.InvalidArguments"
+	dbusErrFailed       = "io.github.quicky.Error.Failed"
+)
+
+// HeadsetPath returns the object path of a headset, e.g.
+// /io/github/quicky/headset/AA_BB_CC_DD_EE_FF for its configured address.
+func HeadsetPath(address string) dbus.ObjectPath {
+	elem := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, address)
+	return DBusPath + "/headset/" + dbus.ObjectPath(elem)
+}
+
+// stateResync is how often the D-Bus front-ends re-read the cached state,
+// catching up with state events Subscribe dropped.
+var stateResync = 10 * time.Second
+
+func dbusError(err error) *dbus.Error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrNotConnected) {
+		return dbus.NewError(dbusErrNotConnected, []any{err.Error()})
+	}
+	return dbus.NewError(dbusErrFailed, []any{err.Error()})
+}
+
+func dbusInvalidArgs(err error) *dbus.Error {
+	return dbus.NewError(dbusErrInvalidArgs, []any{err.Error()})
+}
+
+// dbusProperties maps the state to the properties of DBusHeadsetInterface.
+// Values the headset has not reported are zero: battery levels of 0, an
+// empty ANC mode and so on.
+func dbusProperties(s State) map[string]any {
+	props := map[string]any{
+		"Name":           s.Name,
+		"Address":        s.Address,
+		"Model":          s.Model,
+		"Connected":      s.Connected,
+		"BatteryLeft":    byte(0),
+		"BatteryRight":   byte(0),
+		"BatteryCase":    byte(0),
+		"Charging":       []string{},
+		"ANCMode":        "",
+		"LowLatency":     false,
+		"EQPreset":       byte(0),
+		"DualConnection": false,
+		"Settings":       map[string]string{},
+	}
+	if b := s.Battery; b != nil {
+		props["BatteryLeft"] = b.Left.Level
+		props["BatteryRight"] = b.Right.Level
+		props["BatteryCase"] = b.Case.Level
+		charging := []string{}
+		for _, side := range []struct {
+			name string
+			info BatteryLevel
+		}{{"left", b.Left}, {"right", b.Right}, {"case", b.Case}} {
+			if side.info.Charging {
+				charging = append(charging, side.name)
+			}
+		}
+		props["Charging"] = charging
+	}
+	if s.ANC != "" {
+		props["ANCMode"] = s.ANC
+	}
+	if s.LowLatency != nil {
+		props["LowLatency"] = *s.LowLatency
+	}
+	if s.EQPreset != nil {
+		props["EQPreset"] = *s.EQPreset
+	}
+	if s.DualConnection != nil {
+		props["DualConnection"] = *s.DualConnection
+	}
+	for k, v := range s.Settings {
+		props["Settings"].(map[string]string)[k] = v
+	}
+	return props
+}
+
+// dbusHeadset implements DBusHeadsetInterface. Its exported methods mirror
+// the Client setters.
+type dbusHeadset struct {
+	m     *Manager
+	h     *Headset
+	props *prop.Properties
+}
+
+func (d *dbusHeadset) SetANCScene(scene string) *dbus.Error {
+	s, err := parseScene(scene)
+	if err != nil {
+		return dbusInvalidArgs(err)
+	}
+	return dbusError(d.h.SetANC(s))
+}
+
+func (d *dbusHeadset) SetLowLatency(on bool) *dbus.Error {
+	return dbusError(d.h.SetLowLatency(on))
+}
+
+func (d *dbusHeadset) SetDualConnection(on bool) *dbus.Error {
+	return dbusError(d.h.SetDualConnection(on))
+}
+
+func (d *dbusHeadset) SetSetting(name, value string) *dbus.Error {
+	return dbusError(d.h.SetSetting(context.Background(), name, value))
+}
+
+func (d *dbusHeadset) ApplyProfile(name string) *dbus.Error {
+	p, err := d.m.Profile(name)
+	if err != nil {
+		return dbusInvalidArgs(err)
+	}
+	return dbusError(d.h.ApplyProfile(context.Background(), p))
+}
+
+func (d *dbusHeadset) SetVolume(left, right byte) *dbus.Error {
+	return dbusError(d.h.Do(func(c *quicky.Client) error { return c.SetVolume(left, right) }))
+}
+
+func (d *dbusHeadset) SetInEarDetection(on bool) *dbus.Error {
+	return dbusError(d.h.Do(func(c *quicky.Client) error { return c.SetInEarDetection(on) }))
+}
+
+func (d *dbusHeadset) SetSpatialAudio(on bool) *dbus.Error {
+	return dbusError(d.h.Do(func(c *quicky.Client) error { return c.SetSpatialAudio(on) }))
+}
+
+func (d *dbusHeadset) SetLDAC(on bool) *dbus.Error {
+	return dbusError(d.h.Do(func(c *quicky.Client) error { return c.SetLDAC(on) }))
+}
+
+func (d *dbusHeadset) MusicControl(action string) *dbus.Error {
+	a, err := quicky.ParseMusicAction(action)
+	if err != nil {
+		return dbusInvalidArgs(err)
+	}
+	return dbusError(d.h.Do(func(c *quicky.Client) error { return c.MusicControl(a) }))
+}
+
+// update sets the properties that differ from s, emitting PropertiesChanged
+// for each.
+func (d *dbusHeadset) update(s State) {
+	for name, v := range dbusProperties(s) {
+		if reflect.DeepEqual(d.props.GetMust(DBusHeadsetInterface, name), v) {
+			continue
+		}
+		d.props.SetMust(DBusHeadsetInterface, name, v)
+	}
+}
+
+// dbusManager implements DBusManagerInterface.
+type dbusManager struct {
+	paths []dbus.ObjectPath
+}
+
+func (d *dbusManager) Headsets() ([]dbus.ObjectPath, *dbus.Error) {
+	return d.paths, nil
+}
+
+func exportIntrospection(conn *dbus.Conn, path dbus.ObjectPath, iface introspect.Interface, children []dbus.ObjectPath) error {
+	node := &introspect.Node{
+		Name:       string(path),
+		Interfaces: []introspect.Interface{introspect.IntrospectData, prop.IntrospectData, iface},
+	}
+	for _, c := range children {
+		node.Children = append(node.Children, introspect.Node{Name: strings.TrimPrefix(string(c), string(path)+"/")})
+	}
+	return conn.Export(introspect.NewIntrospectable(node), path, "org.freedesktop.DBus.Introspectable")
+}
+
+// ServeDBus publishes the headsets on conn, usually the session bus, and
+// keeps their properties current until ctx is done. It fails if another
+// process owns DBusName.
+func (m *Manager) ServeDBus(ctx context.Context, conn *dbus.Conn) error {
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	objects := make(map[string]*dbusHeadset, len(m.headsets))
+	manager := &dbusManager{}
+	for _, h := range m.headsets {
+		path := HeadsetPath(h.cfg.Address)
+		obj := &dbusHeadset{m: m, h: h}
+
+		props := prop.Map{DBusHeadsetInterface: map[string]*prop.Prop{}}
+		for name, v := range dbusProperties(h.State()) {
+			emit := prop.EmitTrue
+			if name == "Name" {
+				emit = prop.EmitConst
+			}
+			props[DBusHeadsetInterface][name] = &prop.Prop{Value: v, Emit: emit}
+		}
+		var err error
+		if obj.props, err = prop.Export(conn, path, props); err != nil {
+			return err
+		}
+		if err := conn.Export(obj, path, DBusHeadsetInterface); err != nil {
+			return err
+		}
+		iface := introspect.Interface{
+			Name:       DBusHeadsetInterface,
+			Methods:    introspect.Methods(obj),
+			Properties: obj.props.Introspection(DBusHeadsetInterface),
+		}
+		if err := exportIntrospection(conn, path, iface, nil); err != nil {
+			return err
+		}
+		objects[h.ID()] = obj
+		manager.paths = append(manager.paths, path)
+	}
+
+	if err := conn.Export(manager, DBusPath, DBusManagerInterface); err != nil {
+		return err
+	}
+	iface := introspect.Interface{Name: DBusManagerInterface, Methods: introspect.Methods(manager)}
+	if err := exportIntrospection(conn, DBusPath, iface, manager.paths); err != nil {
+		return err
+	}
+
+	reply, err := conn.RequestName(DBusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("dbus: %s is already owned", DBusName)
+	}
+	defer conn.ReleaseName(DBusName)
+
+	ticker := time.NewTicker(stateResync)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, h := range m.headsets {
+				objects[h.ID()].update(h.State())
+			}
+		case ev := <-events:
+			if ev.Type != EventState {
+				continue
+			}
+			if obj, ok := objects[ev.Device]; ok {
+				obj.update(*ev.State)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// waitChanged waits for a PropertiesChanged signal setting name to want.
+func waitChanged(t *testing.T, signals <-chan *dbus.Signal, name string, want any) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+				continue
+			}
+			changed, _ := sig.Body[1].(map[string]dbus.Variant)
+			if v, ok := changed[name]; ok && v.Value() == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no PropertiesChanged for %s = %v", name, want)
+		}
+	}
+}
+
+func TestServeDBus(t *testing.T) {
+	defer func(d time.Duration) { stateResync = d }(stateResync)
+	stateResync = 50 * time.Millisecond
+
+	addr := privateBus(t)
+	m := newTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- m.ServeDBus(ctx, dialBus(t, addr)) }()
+
+	client := dialBus(t, addr)
+	waitForName(t, client, DBusName)
+
+	var paths []dbus.ObjectPath
+	if err := client.Object(DBusName, DBusPath).Call(DBusManagerInterface+".Headsets", 0).Store(&paths); err != nil {
+		t.Fatal(err)
+	}
+	path := HeadsetPath("AA:BB:CC:DD:EE:01")
+	if len(paths) != 2 || paths[0] != path {
+		t.Fatalf("headsets = %v", paths)
+	}
+
+	obj := client.Object(DBusName, path)
+	var props map[string]dbus.Variant
+	if err := obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, DBusHeadsetInterface).Store(&props); err != nil {
+		t.Fatal(err)
+	}
+	if props["Name"].Value() != "work" || props["Connected"].Value() != false || props["ANCMode"].Value() != "" {
+		t.Errorf("properties = %v", props)
+	}
+
+	if err := client.AddMatchSignal(dbus.WithMatchObjectPath(path), dbus.WithMatchInterface("org.freedesktop.DBus.Properties")); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 16)
+	client.Signal(signals)
+
+	h := m.Headsets()[0]
+	h.update(func(s *State) { s.ANC = "anc/2" })
+	waitChanged(t, signals, "ANCMode", "anc/2")
+
+	// A change that never reached the subscription is picked up by the resync.
+	h.mu.Lock()
+	h.state.LowLatency = ptr(true)
+	h.mu.Unlock()
+	waitChanged(t, signals, "LowLatency", true)
+
+	call := obj.Call(DBusHeadsetInterface+".SetANCScene", 0, "loud")
+	if err, ok := call.Err.(dbus.Error); !ok || err.Name != dbusErrInvalidArgs {
+		t.Errorf("SetANCScene(loud) = %v, want %s", call.Err, dbusErrInvalidArgs)
+	}
+	call = obj.Call(DBusHeadsetInterface+".SetANCScene", 0, "off")
+	if err, ok := call.Err.(dbus.Error); !ok || err.Name != dbusErrNotConnected {
+		t.Errorf("SetANCScene(off) = %v, want %s", call.Err, dbusErrNotConnected)
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,278 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+var ErrNotConnected = errors.New("headset not connected")
+
+const (
+	// connectTimeout bounds one connection attempt.
+	connectTimeout = 30 * time.Second
+	// readTimeout bounds each read-back query.
+	readTimeout = 2 * time.Second
+)
+
+// Headset keeps one configured headset connected and caches its state.
+type Headset struct {
+	cfg    HeadsetConfig
+	m      *Manager
+	client *quicky.Client
+
+	mu    sync.Mutex
+	state State
+}
+
+func newHeadset(m *Manager, cfg HeadsetConfig) (*Headset, error) {
+	// The address parser only accepts upper case hex.
+	client, err := quicky.New(strings.ToUpper(cfg.Address))
+	if err != nil {
+		return nil, fmt.Errorf("headset %s: %w", cfg.Address, err)
+	}
+	return &Headset{
+		cfg:    cfg,
+		m:      m,
+		client: client,
+		state:  State{Name: cfg.Name, Address: cfg.Address},
+	}, nil
+}
+
+// ID is the name the headset is reported under: its configured name, or its
+// address if it has none.
+func (h *Headset) ID() string {
+	if h.cfg.Name != "" {
+		return h.cfg.Name
+	}
+	return h.cfg.Address
+}
+
+// Client returns the underlying client. It is only usable while connected.
+func (h *Headset) Client() *quicky.Client {
+	return h.client
+}
+
+// State returns a copy of the cached state.
+func (h *Headset) State() State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state.clone()
+}
+
+// update applies fn to the state and publishes the result.
+func (h *Headset) update(fn func(*State)) {
+	h.mu.Lock()
+	fn(&h.state)
+	h.state.UpdatedAt = time.Now()
+	s := h.state.clone()
+	h.mu.Unlock()
+	h.m.publish(Event{Device: h.ID(), Type: EventState, State: &s})
+}
+
+// run keeps the headset connected until ctx is done, reconnecting with an
+// exponential backoff.
+func (h *Headset) run(ctx context.Context) {
+	events, unsubscribe := h.client.Subscribe()
+	defer unsubscribe()
+	go h.watch(events)
+
+	backoff := time.Duration(h.m.cfg.ReconnectMin)
+	for {
+		connected, err := h.session(ctx)
+		h.client.Disconnect()
+		if ctx.Err() != nil {
+			h.update(func(s *State) { s.Connected = false; s.Error = "" })
+			return
+		}
+		h.update(func(s *State) {
+			s.Connected = false
+			s.Error = err.Error()
+		})
+		if connected {
+			backoff = time.Duration(h.m.cfg.ReconnectMin)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Duration(h.m.cfg.ReconnectMax))
+	}
+}
+
+// session connects once and returns when the connection is lost. connected
+// reports whether the connection was established at all.
+func (h *Headset) session(ctx context.Context) (connected bool, err error) {
+	connectCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+	err = h.client.Connect(connectCtx)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	h.update(func(s *State) {
+		s.Connected = true
+		s.Error = ""
+		s.Address = h.client.Address()
+		if p := h.client.Product(); p != nil {
+			s.Model = p.Title
+			s.VendorID = uint16(p.VendorId)
+		}
+	})
+
+	h.refresh(ctx)
+	if h.cfg.Profile != "" {
+		if err := h.m.applyProfile(ctx, h, h.cfg.Profile); err != nil {
+			h.m.logf("%s: profile %s: %v", h.ID(), h.cfg.Profile, err)
+		}
+	}
+
+	ticker := time.NewTicker(time.Duration(h.m.cfg.HealthInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-ticker.C:
+		}
+		if !h.client.Connected() {
+			return true, ErrNotConnected
+		}
+		battery, err := h.client.ReadBattery()
+		if err != nil {
+			return true, fmt.Errorf("health check: %w", err)
+		}
+		h.apply(quicky.Event{Type: quicky.EventBattery, CmdID: byte(quicky.EventBattery), Payload: quicky.BatteryEvent{Battery: battery}})
+	}
+}
+
+// refresh reads everything the state cache holds. Values the device does not
+// report are left as they are.
+func (h *Headset) refresh(ctx context.Context) {
+	if battery, err := h.client.ReadBattery(); err == nil {
+		h.apply(quicky.Event{Type: quicky.EventBattery, CmdID: byte(quicky.EventBattery), Payload: quicky.BatteryEvent{Battery: battery}})
+	}
+	if version, err := h.client.ReadVersion(); err == nil {
+		h.apply(quicky.Event{Type: quicky.EventVersion, CmdID: byte(quicky.EventVersion), Payload: quicky.VersionEvent{Version: version}})
+	}
+	// Answers arrive as notifications and are applied by watch.
+	for _, cmd := range []quicky.EventType{quicky.EventANCSetting, quicky.EventLowLatency, quicky.EventEQV2, quicky.EventDualConnection} {
+		h.query(ctx, byte(cmd))
+	}
+	if _, err := h.client.Settings(ctx); err != nil && !errors.Is(err, quicky.ErrNoProduct) {
+		h.m.logf("%s: settings: %v", h.ID(), err)
+	}
+}
+
+func (h *Headset) query(ctx context.Context, cmdID byte) {
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+	h.client.Query(ctx, cmdID)
+}
+
+// watch applies notifications to the state and forwards them to
+// subscribers.
+func (h *Headset) watch(events <-chan quicky.Event) {
+	for ev := range events {
+		h.m.publish(Event{Device: h.ID(), Type: EventNotification, Notification: newNotification(ev)})
+		h.apply(ev)
+	}
+}
+
+func (h *Headset) apply(ev quicky.Event) {
+	var settings []quicky.ProductSetting
+	if p := h.client.Product(); p != nil {
+		settings = p.Settings()
+	}
+	h.mu.Lock()
+	changed := h.state.apply(ev, settings)
+	s := h.state.clone()
+	h.mu.Unlock()
+	if changed {
+		h.m.publish(Event{Device: h.ID(), Type: EventState, State: &s})
+	}
+}
+
+// SetSetting sets a product setting by name and reads it back.
+func (h *Headset) SetSetting(ctx context.Context, name, value string) error {
+	if !h.client.Connected() {
+		return ErrNotConnected
+	}
+	if err := h.client.SetSetting(ctx, name, value); err != nil {
+		return err
+	}
+	if s, err := h.client.ProductSetting(name); err == nil && s.Opcode != 0 && s.Kind != quicky.SettingAction {
+		h.query(ctx, s.Opcode)
+	}
+	return nil
+}
+
+// Do runs fn with the client if the headset is connected.
+func (h *Headset) Do(fn func(*quicky.Client) error) error {
+	if !h.client.Connected() {
+		return ErrNotConnected
+	}
+	return fn(h.client)
+}
+
+// SetANC sends an ANC scene and records it in the state.
+func (h *Headset) SetANC(scene quicky.ANCScene) error {
+	if err := h.Do(func(c *quicky.Client) error { return c.SetANCScene(scene) }); err != nil {
+		return err
+	}
+	h.update(func(s *State) { s.ANC = scene.String() })
+	return nil
+}
+
+// SetLowLatency switches game mode and records it in the state.
+func (h *Headset) SetLowLatency(on bool) error {
+	if err := h.Do(func(c *quicky.Client) error { return c.SetLowLatency(on) }); err != nil {
+		return err
+	}
+	h.update(func(s *State) { s.LowLatency = &on })
+	return nil
+}
+
+// SetDualConnection switches multipoint and records it in the state.
+func (h *Headset) SetDualConnection(on bool) error {
+	if err := h.Do(func(c *quicky.Client) error { return c.SetDualConnection(on) }); err != nil {
+		return err
+	}
+	h.update(func(s *State) { s.DualConnection = &on })
+	return nil
+}
+
+// ApplyProfile applies the ANC scene and then the settings of p, in name
+// order. It keeps going after a failed setting and returns all errors.
+func (h *Headset) ApplyProfile(ctx context.Context, p Profile) error {
+	if !h.client.Connected() {
+		return ErrNotConnected
+	}
+	var errs []error
+	if p.ANC != "" {
+		scene, err := parseScene(p.ANC)
+		if err == nil {
+			err = h.SetANC(scene)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("anc: %w", err))
+		}
+	}
+	names := make([]string, 0, len(p.Settings))
+	for name := range p.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := h.SetSetting(ctx, name, p.Settings[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// openAPI describes the routes of Handler.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+// sseKeepAlive is how often an idle event stream gets a comment line so
+// proxies do not close it.
+const sseKeepAlive = 15 * time.Second
+
+// Handler returns an http.Handler serving the headsets as REST:
+//
+//	GET /devices                 states of all headsets
+//	GET /devices/{mac}/state     state of one headset
+//	PUT /devices/{mac}/anc       set the ANC scene, body {"scene": "anc/2"}
+//	GET /events[?device=NAME]    Server-Sent Events stream of Event
+//	GET /openapi.json            OpenAPI 3 description
+//
+// {mac} is a configured name or any address of the headset. The handler can
+// be mounted below a prefix with http.StripPrefix. It does no
+// authentication.
+func (m *Manager) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices", m.handleDevices)
+	mux.HandleFunc("GET /devices/{mac}/state", m.handleState)
+	mux.HandleFunc("PUT /devices/{mac}/anc", m.handleANC)
+	mux.HandleFunc("GET /events", m.handleEvents)
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError maps err to a status: 404 for unknown headsets, 503 while
+// disconnected, 400 for bad input and 502 for device errors.
+func writeError(w http.ResponseWriter, err error) {
+	status, msg := http.StatusBadGateway, err.Error()
+	var rpcErr *RPCError
+	switch {
+	case errors.Is(err, ErrUnknownHeadset):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNotConnected):
+		status = http.StatusServiceUnavailable
+	case errors.As(err, &rpcErr) && rpcErr.Code == CodeInvalidParams:
+		status, msg = http.StatusBadRequest, rpcErr.Message
+	}
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func (m *Manager) handleDevices(w http.ResponseWriter, r *http.Request) {
+	states := make([]State, len(m.headsets))
+	for i, h := range m.headsets {
+		states[i] = h.State()
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+func (m *Manager) handleState(w http.ResponseWriter, r *http.Request) {
+	h, err := m.Headset(r.PathValue("mac"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.State())
+}
+
+func (m *Manager) handleANC(w http.ResponseWriter, r *http.Request) {
+	h, err := m.Headset(r.PathValue("mac"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var body struct {
+		Scene string `json:"scene"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+		writeError(w, invalidParams(fmt.Errorf("invalid body: %w", err)))
+		return
+	}
+	scene, err := parseScene(body.Scene)
+	if err != nil {
+		writeError(w, invalidParams(err))
+		return
+	}
+	if err := h.SetANC(scene); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.State())
+}
+
+// handleEvents streams events as Server-Sent Events. The SSE event name is
+// the Event type, the data its JSON.
+func (m *Manager) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+		return
+	}
+	var device string
+	if key := r.URL.Query().Get("device"); key != "" {
+		h, err := m.Headset(key)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		device = h.ID()
+	}
+
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case ev := <-events:
+			if device != "" && ev.Device != device {
+				continue
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPDevices(t *testing.T) {
+	srv := httptest.NewServer(newTestManager(t).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	var states []State
+	if err := json.NewDecoder(resp.Body).Decode(&states); err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || states[0].Name != "work" || states[1].Address != "AA:BB:CC:DD:EE:02" {
+		t.Errorf("devices = %+v", states)
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(newTestManager(t).Handler())
+	defer srv.Close()
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/devices/nope/state", "", http.StatusNotFound},
+		{http.MethodPut, "/devices/nope/anc", `{"scene": "anc/2"}`, http.StatusNotFound},
+		{http.MethodPut, "/devices/work/anc", `{"scene":`, http.StatusBadRequest},
+		{http.MethodPut, "/devices/work/anc", `{"scene": "loud"}`, http.StatusBadRequest},
+		{http.MethodPut, "/devices/work/anc", `{"scene": "anc/9"}`, http.StatusBadRequest},
+		{http.MethodPut, "/devices/work/anc", `{"scene": "anc/2"}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || body.Error == "" {
+			t.Errorf("%s %s %s: status %d, error %q, want %d", tt.method, tt.path, tt.body, resp.StatusCode, body.Error, tt.status)
+		}
+	}
+}
+
+func TestHTTPEvents(t *testing.T) {
+	m := newTestManager(t)
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events?device=work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+
+	// The handler has subscribed once the headers are sent. The first
+	// event is for another headset and filtered out.
+	m.publish(Event{Device: "AA:BB:CC:DD:EE:02", Type: EventState, State: &State{Name: "other"}})
+	m.publish(Event{Device: "work", Type: EventState, State: &State{Name: "work", Connected: true}})
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if lines[0] != "event: state" || lines[2] != "" {
+		t.Fatalf("frame = %q", lines)
+	}
+	data, ok := strings.CutPrefix(lines[1], "data: ")
+	if !ok {
+		t.Fatalf("frame = %q", lines)
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(data), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Device != "work" || ev.State == nil || !ev.State.Connected {
+		t.Errorf("event = %+v", ev)
+	}
+}
@@ -0,0 +1,174 @@
+package daemon
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+// EventType tells what an Event carries.
+type EventType string
+
+const (
+	// EventNotification forwards a notification from the headset.
+	EventNotification EventType = "notification"
+	// EventState carries the new state after it changed, including
+	// connects and disconnects.
+	EventState EventType = "state"
+)
+
+type Event struct {
+	Device       string        `json:"device"`
+	Type         EventType     `json:"type"`
+	Notification *Notification `json:"notification,omitempty"`
+	State        *State        `json:"state,omitempty"`
+}
+
+// Notification is a device notification in a JSON friendly form.
+type Notification struct {
+	CmdID byte   `json:"cmd"`
+	Raw   string `json:"raw"`
+	// Kind is the name of the typed payload, e.g. "BatteryEvent".
+	Kind    string `json:"kind,omitempty"`
+	Payload any    `json:"payload,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newNotification(ev quicky.Event) *Notification {
+	n := &Notification{CmdID: ev.CmdID, Raw: strings.ToUpper(hex.EncodeToString(ev.Raw))}
+	if ev.Payload != nil {
+		n.Kind = reflect.TypeOf(ev.Payload).Name()
+		n.Payload = ev.Payload
+	}
+	if ev.Error != nil {
+		n.Error = ev.Error.Error()
+	}
+	return n
+}
+
+var ErrUnknownHeadset = errors.New("unknown headset")
+
+// Manager runs the configured headsets and fans their events out.
+type Manager struct {
+	cfg      Config
+	headsets []*Headset
+
+	// Logf, if set, receives errors that have no caller to return to, such
+	// as a profile failing after a reconnect.
+	Logf func(format string, args ...any)
+
+	subMu sync.Mutex
+	subs  map[chan Event]struct{}
+}
+
+// New creates a manager for cfg. Nothing connects until Run.
+func New(cfg Config) (*Manager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.setDefaults()
+	m := &Manager{cfg: cfg}
+	for _, hc := range cfg.Headsets {
+		h, err := newHeadset(m, hc)
+		if err != nil {
+			return nil, err
+		}
+		m.headsets = append(m.headsets, h)
+	}
+	return m, nil
+}
+
+func (m *Manager) logf(format string, args ...any) {
+	if m.Logf != nil {
+		m.Logf(format, args...)
+	}
+}
+
+// Config returns the configuration with defaults filled in.
+func (m *Manager) Config() Config {
+	return m.cfg
+}
+
+// Run keeps every headset connected until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, h := range m.headsets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Headsets returns the headsets in configuration order.
+func (m *Manager) Headsets() []*Headset {
+	return m.headsets
+}
+
+// Headset finds a headset by name or address, ignoring case.
+func (m *Manager) Headset(key string) (*Headset, error) {
+	for _, h := range m.headsets {
+		if h.State().matches(key, h.cfg) {
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownHeadset, key)
+}
+
+// Profile returns a configured profile.
+func (m *Manager) Profile(name string) (Profile, error) {
+	p, ok := m.cfg.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+	return p, nil
+}
+
+func (m *Manager) applyProfile(ctx context.Context, h *Headset, name string) error {
+	p, err := m.Profile(name)
+	if err != nil {
+		return err
+	}
+	return h.ApplyProfile(ctx, p)
+}
+
+// Subscribe returns a channel receiving the events of every headset. Events
+// are dropped for subscribers that fall behind. Call the returned function to
+// unsubscribe; it closes the channel.
+func (m *Manager) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+	m.subMu.Lock()
+	if m.subs == nil {
+		m.subs = make(map[chan Event]struct{})
+	}
+	m.subs[ch] = struct{}{}
+	m.subMu.Unlock()
+
+	return ch, func() {
+		m.subMu.Lock()
+		defer m.subMu.Unlock()
+		if _, ok := m.subs[ch]; ok {
+			delete(m.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (m *Manager) publish(ev Event) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	for ch := range m.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+// MPRIS2 names, see https://specifications.freedesktop.org/mpris-spec/latest/.
+const (
+	mprisPrefix          = "org.mpris.MediaPlayer2."
+	mprisPath            = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	mprisRootInterface   = "org.mpris.MediaPlayer2"
+	mprisPlayerInterface = "org.mpris.MediaPlayer2.Player"
+
+	// MPRISNamePrefix starts the bus names of the on-device players. They
+	// are never the target of forwarded gestures.
+	MPRISNamePrefix = mprisPrefix + "quicky"
+)
+
+// MPRISName returns the bus name of a headset's on-device player, e.g.
+// org.mpris.MediaPlayer2.quicky.headset_AA_BB_CC_DD_EE_FF.
+func MPRISName(address string) string {
+	path := HeadsetPath(address)
+	return MPRISNamePrefix + ".headset_" + string(path[strings.LastIndexByte(string(path), '/')+1:])
+}
+
+// ServeMPRIS bridges the headsets and MPRIS2 until ctx is done:
+//
+//   - music control notifications (0x04), sent by earbud gestures, are
+//     forwarded to the active desktop player;
+//   - the music library of headsets that keep one on the device (0x3A/0x3B)
+//     is published as an MPRIS2 player while they are connected.
+//
+// Every player needs its own bus connection, so dial is called once for the
+// forwarder and once per published player. dbus.ConnectSessionBus fits.
+func (m *Manager) ServeMPRIS(ctx context.Context, dial func(...dbus.ConnOption) (*dbus.Conn, error)) error {
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	players := make(map[string]*playerRun)
+	defer func() {
+		for _, run := range players {
+			run.stop()
+		}
+	}()
+	failed := make(chan *playerRun)
+	// syncPlayer starts or stops the player of h to match its cached state,
+	// so a dropped connect event is caught up with by the next resync.
+	syncPlayer := func(h *Headset) {
+		run, running := players[h.ID()]
+		connected := h.State().Connected
+		switch {
+		case connected && !running:
+			playerCtx, cancel := context.WithCancel(ctx)
+			run = &playerRun{id: h.ID(), stop: cancel}
+			players[run.id] = run
+			go func() {
+				err := m.servePlayer(playerCtx, h, dial)
+				if err == nil || playerCtx.Err() != nil {
+					return
+				}
+				m.logf("%s: mpris player: %v", h.ID(), err)
+				select {
+				case failed <- run:
+				case <-playerCtx.Done():
+				}
+			}()
+		case !connected && running:
+			run.stop()
+			delete(players, run.id)
+		}
+	}
+
+	ticker := time.NewTicker(stateResync)
+	defer ticker.Stop()
+	for {
+		var ev Event
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, h := range m.headsets {
+				syncPlayer(h)
+			}
+			continue
+		case run := <-failed:
+			// Forget the failed player; the next resync starts it again.
+			if players[run.id] == run {
+				run.stop()
+				delete(players, run.id)
+			}
+			continue
+		case ev = <-events:
+		}
+		switch ev.Type {
+		case EventNotification:
+			if ev.Notification.Error != "" {
+				continue
+			}
+			if mc, ok := ev.Notification.Payload.(quicky.MusicControlEvent); ok {
+				if err := forwardMusicControl(conn, quicky.MusicAction(mc.Action)); err != nil {
+					m.logf("%s: mpris: %v", ev.Device, err)
+				}
+			}
+		case EventState:
+			if h, err := m.Headset(ev.Device); err == nil {
+				syncPlayer(h)
+			}
+		}
+	}
+}
+
+// playerRun is a started on-device player.
+type playerRun struct {
+	id   string
+	stop context.CancelFunc
+}
+
+var mprisMethods = map[quicky.MusicAction]string{
+	quicky.MusicPlay:     "Play",
+	quicky.MusicPause:    "Pause",
+	quicky.MusicPrevious: "Previous",
+	quicky.MusicNext:     "Next",
+}
+
+// forwardMusicControl calls the MPRIS method for action on the active player.
+func forwardMusicControl(conn *dbus.Conn, action quicky.MusicAction) error {
+	method, ok := mprisMethods[action]
+	if !ok {
+		return fmt.Errorf("no MPRIS method for music action %s", action)
+	}
+	name, err := activePlayer(conn)
+	if err != nil {
+		return err
+	}
+	return conn.Object(name, mprisPath).Call(mprisPlayerInterface+"."+method, 0).Err
+}
+
+// activePlayer picks the desktop player to control: the first playing one,
+// else the first paused one, else the first one, in bus name order.
+func activePlayer(conn *dbus.Conn) (string, error) {
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return "", err
+	}
+	var players []string
+	for _, name := range names {
+		if strings.HasPrefix(name, mprisPrefix) && !strings.HasPrefix(name, MPRISNamePrefix) {
+			players = append(players, name)
+		}
+	}
+	if len(players) == 0 {
+		return "", errors.New("no MPRIS player running")
+	}
+	sort.Strings(players)
+	rank := map[string]int{"Playing": 0, "Paused": 1}
+	best, bestRank := players[0], len(rank)
+	for _, name := range players {
+		v, err := conn.Object(name, mprisPath).GetProperty(mprisPlayerInterface + ".PlaybackStatus")
+		if err != nil {
+			continue
+		}
+		status, _ := v.Value().(string)
+		if r, ok := rank[status]; ok && r < bestRank {
+			best, bestRank = name, r
+		}
+	}
+	return best, nil
+}
+
+// servePlayer publishes the headset's music library while ctx is live. It
+// returns nil at once for headsets without one, i.e. when the track list
+// query times out or comes back empty, and an error if it should be retried.
+func (m *Manager) servePlayer(ctx context.Context, h *Headset, dial func(...dbus.ConnOption) (*dbus.Conn, error)) error {
+	player := h.client.Player()
+	defer player.Close()
+
+	queryCtx, cancel := context.WithTimeout(ctx, readTimeout)
+	tracks, err := player.Tracks(queryCtx)
+	cancel()
+	if errors.Is(err, context.DeadlineExceeded) || (err == nil && len(tracks) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("tracks: %w", err)
+	}
+	queryCtx, cancel = context.WithTimeout(ctx, readTimeout)
+	player.Status(queryCtx)
+	cancel()
+
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	p := &mprisPlayer{h: h, player: player, tracks: tracks}
+	if err := p.export(conn); err != nil {
+		return err
+	}
+	name := MPRISName(h.cfg.Address)
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("%s is already owned", name)
+	}
+
+	statuses := player.NowPlaying(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-statuses:
+			p.update()
+		}
+	}
+}
+
+// mprisRoot implements org.mpris.MediaPlayer2. The player has no window.
+type mprisRoot struct{}
+
+func (mprisRoot) Raise() *dbus.Error { return nil }
+func (mprisRoot) Quit() *dbus.Error  { return nil }
+
+// mprisPlayer implements org.mpris.MediaPlayer2.Player on top of the
+// on-device library. Tracks have no titles or lengths, so seeking is not
+// supported and tracks are named by their position in the list.
+type mprisPlayer struct {
+	h      *Headset
+	player *quicky.Player
+	tracks []quicky.Track
+	props  *prop.Properties
+}
+
+func (p *mprisPlayer) Next() *dbus.Error     { return p.result(p.player.Next()) }
+func (p *mprisPlayer) Previous() *dbus.Error { return p.result(p.player.Previous()) }
+func (p *mprisPlayer) Pause() *dbus.Error    { return p.result(p.player.Pause()) }
+func (p *mprisPlayer) Stop() *dbus.Error     { return p.result(p.player.Pause()) }
+
+func (p *mprisPlayer) Play() *dbus.Error {
+	if _, ok := p.player.Current(); !ok {
+		return p.Next()
+	}
+	return p.result(p.player.Resume())
+}
+
+func (p *mprisPlayer) PlayPause() *dbus.Error {
+	if s, ok := p.player.Current(); ok && s.IsPlaying {
+		return p.Pause()
+	}
+	return p.Play()
+}
+
+// SeekBy is exported as Seek; the name would clash with io.Seeker.
+func (p *mprisPlayer) SeekBy(offset int64) *dbus.Error                          { return nil }
+func (p *mprisPlayer) SetPosition(track dbus.ObjectPath, pos int64) *dbus.Error { return nil }
+func (p *mprisPlayer) OpenUri(uri string) *dbus.Error                           { return nil }
+
+// result updates the properties after a successful command; the device does
+// not always answer with a status notification.
+func (p *mprisPlayer) result(err error) *dbus.Error {
+	if err != nil {
+		return dbusError(err)
+	}
+	p.update()
+	return nil
+}
+
+func trackPath(id uint32) dbus.ObjectPath {
+	return dbus.ObjectPath(fmt.Sprintf("/io/github/quicky/track/%d", id))
+}
+
+// playerProperties maps the player's status to org.mpris.MediaPlayer2.Player
+// properties that change.
+func (p *mprisPlayer) playerProperties() map[string]any {
+	s, ok := p.player.Current()
+	props := map[string]any{
+		"PlaybackStatus": "Stopped",
+		"Metadata":       map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack"))},
+	}
+	if !ok {
+		return props
+	}
+	props["PlaybackStatus"] = "Paused"
+	if s.IsPlaying {
+		props["PlaybackStatus"] = "Playing"
+	}
+	title := fmt.Sprintf("Track %d", s.MusicID)
+	for i, t := range p.tracks {
+		if t.ID == s.MusicID {
+			title = fmt.Sprintf("Track %d", i+1)
+		}
+	}
+	props["Metadata"] = map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(trackPath(s.MusicID)),
+		"xesam:title":   dbus.MakeVariant(title),
+		"xesam:album":   dbus.MakeVariant(p.identity()),
+	}
+	return props
+}
+
+func (p *mprisPlayer) identity() string {
+	if model := p.h.State().Model; model != "" {
+		return model
+	}
+	return "QCY " + p.h.ID()
+}
+
+func (p *mprisPlayer) update() {
+	for name, v := range p.playerProperties() {
+		if reflect.DeepEqual(p.props.GetMust(mprisPlayerInterface, name), v) {
+			continue
+		}
+		p.props.SetMust(mprisPlayerInterface, name, v)
+	}
+}
+
+func (p *mprisPlayer) export(conn *dbus.Conn) error {
+	root := map[string]*prop.Prop{
+		"CanQuit":             {Value: false, Emit: prop.EmitConst},
+		"CanRaise":            {Value: false, Emit: prop.EmitConst},
+		"HasTrackList":        {Value: false, Emit: prop.EmitConst},
+		"Identity":            {Value: p.identity(), Emit: prop.EmitConst},
+		"SupportedUriSchemes": {Value: []string{}, Emit: prop.EmitConst},
+		"SupportedMimeTypes":  {Value: []string{}, Emit: prop.EmitConst},
+	}
+	player := map[string]*prop.Prop{
+		"Rate":          {Value: 1.0, Emit: prop.EmitConst},
+		"MinimumRate":   {Value: 1.0, Emit: prop.EmitConst},
+		"MaximumRate":   {Value: 1.0, Emit: prop.EmitConst},
+		"Volume":        {Value: 1.0, Emit: prop.EmitConst},
+		"Position":      {Value: int64(0), Emit: prop.EmitFalse},
+		"CanGoNext":     {Value: true, Emit: prop.EmitConst},
+		"CanGoPrevious": {Value: true, Emit: prop.EmitConst},
+		"CanPlay":       {Value: true, Emit: prop.EmitConst},
+		"CanPause":      {Value: true, Emit: prop.EmitConst},
+		"CanSeek":       {Value: false, Emit: prop.EmitConst},
+		"CanControl":    {Value: true, Emit: prop.EmitConst},
+	}
+	for name, v := range p.playerProperties() {
+		player[name] = &prop.Prop{Value: v, Emit: prop.EmitTrue}
+	}
+
+	var err error
+	p.props, err = prop.Export(conn, mprisPath, prop.Map{mprisRootInterface: root, mprisPlayerInterface: player})
+	if err != nil {
+		return err
+	}
+	if err := conn.Export(mprisRoot{}, mprisPath, mprisRootInterface); err != nil {
+		return err
+	}
+	rename := map[string]string{"SeekBy": "Seek"}
+	if err := conn.ExportWithMap(p, rename, mprisPath, mprisPlayerInterface); err != nil {
+		return err
+	}
+	methods := introspect.Methods(p)
+	for i := range methods {
+		if name, ok := rename[methods[i].Name]; ok {
+			methods[i].Name = name
+		}
+	}
+	node := &introspect.Node{
+		Name: string(mprisPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{Name: mprisRootInterface, Methods: introspect.Methods(mprisRoot{}), Properties: p.props.Introspection(mprisRootInterface)},
+			{Name: mprisPlayerInterface, Methods: methods, Properties: p.props.Introspection(mprisPlayerInterface)},
+		},
+	}
+	return conn.Export(introspect.NewIntrospectable(node), mprisPath, "org.freedesktop.DBus.Introspectable")
+}
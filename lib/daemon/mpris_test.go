@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+// fakePlayer is a desktop MPRIS player that records the methods called.
+type fakePlayer struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (p *fakePlayer) record(method string) *dbus.Error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, method)
+	return nil
+}
+
+func (p *fakePlayer) Play() *dbus.Error  { return p.record("Play") }
+func (p *fakePlayer) Pause() *dbus.Error { return p.record("Pause") }
+func (p *fakePlayer) Next() *dbus.Error  { return p.record("Next") }
+
+func (p *fakePlayer) Previous() *dbus.Error { return p.record("Previous") }
+
+func (p *fakePlayer) called() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.calls...)
+}
+
+// startFakePlayer publishes a player named mprisPrefix+name with the given
+// playback status on its own connection.
+func startFakePlayer(t *testing.T, addr, name, status string) *fakePlayer {
+	t.Helper()
+	conn := dialBus(t, addr)
+	p := &fakePlayer{}
+	if err := conn.Export(p, mprisPath, mprisPlayerInterface); err != nil {
+		t.Fatal(err)
+	}
+	props := prop.Map{mprisPlayerInterface: {"PlaybackStatus": {Value: status, Emit: prop.EmitTrue}}}
+	if _, err := prop.Export(conn, mprisPath, props); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := conn.RequestName(mprisPrefix+name, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("request name %s: %v %v", name, reply, err)
+	}
+	return p
+}
+
+func TestForwardMusicControl(t *testing.T) {
+	addr := privateBus(t)
+	conn := dialBus(t, addr)
+
+	if err := forwardMusicControl(conn, quicky.MusicNext); err == nil {
+		t.Fatal("forwarded without a player")
+	}
+
+	paused := startFakePlayer(t, addr, "a", "Paused")
+	playing := startFakePlayer(t, addr, "b", "Playing")
+	// On-device players are never the target, even when playing.
+	own := startFakePlayer(t, addr, "quicky.headset_X", "Playing")
+
+	if err := forwardMusicControl(conn, quicky.MusicNext); err != nil {
+		t.Fatal(err)
+	}
+	if got := playing.called(); len(got) != 1 || got[0] != "Next" {
+		t.Errorf("playing player calls = %v, want [Next]", got)
+	}
+	if len(paused.called()) != 0 || len(own.called()) != 0 {
+		t.Errorf("other players called: %v, %v", paused.called(), own.called())
+	}
+	if err := forwardMusicControl(conn, quicky.MusicAction(0x7f)); err == nil {
+		t.Error("unknown action forwarded")
+	}
+}
+
+// logRecorder collects Manager.Logf output.
+type logRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *logRecorder) logf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func (r *logRecorder) count(substr string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, l := range r.lines {
+		if strings.Contains(l, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestServeMPRIS(t *testing.T) {
+	defer func(d time.Duration) { stateResync = d }(stateResync)
+	stateResync = 20 * time.Millisecond
+
+	addr := privateBus(t)
+	m := newTestManager(t)
+	logs := &logRecorder{}
+	m.Logf = logs.logf
+	dial := func(opts ...dbus.ConnOption) (*dbus.Conn, error) {
+		return dbus.Connect(addr, opts...)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- m.ServeMPRIS(ctx, dial) }()
+
+	desktop := startFakePlayer(t, addr, "desktop", "Playing")
+	gesture := Event{Device: "work", Type: EventNotification, Notification: &Notification{
+		CmdID:   0x04,
+		Payload: quicky.MusicControlEvent{Action: byte(quicky.MusicPlay)},
+	}}
+	// ServeMPRIS subscribes asynchronously; repeat until a gesture arrives.
+	waitFor(t, "forwarded gesture", func() bool {
+		m.publish(gesture)
+		return len(desktop.called()) > 0
+	})
+	if got := desktop.called()[0]; got != "Play" {
+		t.Errorf("forwarded %s, want Play", got)
+	}
+
+	// The headset is marked connected without a state event, as if the
+	// event had been dropped. The resync starts its player; the client is
+	// not really connected, so the player fails and is retried.
+	h := m.Headsets()[0]
+	h.mu.Lock()
+	h.state.Connected = true
+	h.mu.Unlock()
+	waitFor(t, "player retried", func() bool {
+		return logs.count("work: mpris player:") >= 2
+	})
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,307 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+)
+
+// The daemon speaks JSON-RPC 2.0 over a Unix socket, one JSON object per
+// line. After subscribe-events the connection also receives "event"
+// notifications.
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeServerError    = -32000
+)
+
+// RPCError is a JSON-RPC error object.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// nullID answers requests whose ID could not be read.
+var nullID = json.RawMessage("null")
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// DeviceParams names a headset by configured name or address.
+type DeviceParams struct {
+	Device string `json:"device"`
+}
+
+type SetSettingParams struct {
+	Device string `json:"device"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+}
+
+type SetANCParams struct {
+	Device string `json:"device"`
+	// Scene is "off", "anc/2" or a packed hex value such as "0x010200".
+	Scene string `json:"scene"`
+}
+
+type ApplyProfileParams struct {
+	Device  string `json:"device"`
+	Profile string `json:"profile"`
+}
+
+// Listen creates the Unix socket at path, replacing a stale one. The socket
+// is only accessible by the current user.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s: daemon already running", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Serve answers JSON-RPC requests on ln until ctx is done.
+func (m *Manager) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go m.serveConn(ctx, conn)
+	}
+}
+
+// rpcConn serialises writes from request handling and event forwarding.
+type rpcConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+	enc  *json.Encoder
+}
+
+func (c *rpcConn) write(v rpcMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v.JSONRPC = "2.0"
+	return c.enc.Encode(v)
+}
+
+func (m *Manager) serveConn(ctx context.Context, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	c := &rpcConn{conn: conn, enc: json.NewEncoder(conn)}
+	subscribed := false
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			c.write(rpcMessage{ID: nullID, Error: &RPCError{CodeParseError, err.Error()}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			id := req.ID
+			if id == nil {
+				id = nullID
+			}
+			c.write(rpcMessage{ID: id, Error: &RPCError{CodeInvalidRequest, "invalid request"}})
+			continue
+		}
+
+		var result any
+		var err error
+		if req.Method == "subscribe-events" {
+			var p DeviceParams
+			if err = decodeParams(req.Params, &p); err == nil && p.Device != "" {
+				_, err = m.Headset(p.Device)
+				err = invalidParams(err)
+			}
+			if err == nil && !subscribed {
+				subscribed = true
+				go m.forwardEvents(ctx, c, p.Device)
+			}
+			result = true
+		} else {
+			result, err = m.call(ctx, req.Method, req.Params)
+		}
+		if req.ID == nil {
+			continue
+		}
+		resp := rpcMessage{ID: req.ID, Result: result}
+		if err != nil {
+			resp.Result = nil
+			resp.Error = toRPCError(err)
+		}
+		if c.write(resp) != nil {
+			return
+		}
+	}
+}
+
+// forwardEvents sends "event" notifications until ctx is done.
+func (m *Manager) forwardEvents(ctx context.Context, c *rpcConn, device string) {
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+	var h *Headset
+	if device != "" {
+		h, _ = m.Headset(device)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			if h != nil && ev.Device != h.ID() {
+				continue
+			}
+			if c.write(rpcMessage{Method: "event", Params: ev}) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (m *Manager) call(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "list-devices":
+		states := make([]State, len(m.headsets))
+		for i, h := range m.headsets {
+			states[i] = h.State()
+		}
+		return states, nil
+	case "get-state":
+		var p DeviceParams
+		h, err := m.headsetParams(params, &p, &p.Device)
+		if err != nil {
+			return nil, err
+		}
+		return h.State(), nil
+	case "set-setting":
+		var p SetSettingParams
+		h, err := m.headsetParams(params, &p, &p.Device)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.SetSetting(ctx, p.Name, p.Value); err != nil {
+			return nil, err
+		}
+		return h.State(), nil
+	case "set-anc":
+		var p SetANCParams
+		h, err := m.headsetParams(params, &p, &p.Device)
+		if err != nil {
+			return nil, err
+		}
+		scene, err := parseScene(p.Scene)
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		if err := h.SetANC(scene); err != nil {
+			return nil, err
+		}
+		return h.State(), nil
+	case "apply-profile":
+		var p ApplyProfileParams
+		h, err := m.headsetParams(params, &p, &p.Device)
+		if err != nil {
+			return nil, err
+		}
+		profile, err := m.Profile(p.Profile)
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		if err := h.ApplyProfile(ctx, profile); err != nil {
+			return nil, err
+		}
+		return h.State(), nil
+	}
+	return nil, &RPCError{CodeMethodNotFound, fmt.Sprintf("unknown method %q", method)}
+}
+
+// headsetParams decodes params into p and looks up the headset *device names.
+func (m *Manager) headsetParams(params json.RawMessage, p any, device *string) (*Headset, error) {
+	if err := decodeParams(params, p); err != nil {
+		return nil, err
+	}
+	if *device == "" {
+		return nil, invalidParams(errors.New("device is required"))
+	}
+	h, err := m.Headset(*device)
+	return h, invalidParams(err)
+}
+
+func decodeParams(params json.RawMessage, p any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, p); err != nil {
+		return invalidParams(err)
+	}
+	return nil
+}
+
+func invalidParams(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RPCError{CodeInvalidParams, err.Error()}
+}
+
+func toRPCError(err error) *RPCError {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	return &RPCError{CodeServerError, err.Error()}
+}
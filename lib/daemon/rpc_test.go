@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m, err := New(Config{
+		Headsets: []HeadsetConfig{
+			{Name: "work", Address: "AA:BB:CC:DD:EE:01"},
+			{Address: "AA:BB:CC:DD:EE:02"},
+		},
+		Profiles: map[string]Profile{"quiet": {ANC: "anc/2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+type rpcTestReply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+	hasID  bool
+}
+
+// rpcSession serves one connection of m over a pipe.
+func rpcSession(t *testing.T, m *Manager) func(line string) rpcTestReply {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	client, server := net.Pipe()
+	go m.serveConn(ctx, server)
+	t.Cleanup(func() {
+		cancel()
+		client.Close()
+	})
+	r := bufio.NewScanner(client)
+	return func(line string) rpcTestReply {
+		t.Helper()
+		client.SetDeadline(time.Now().Add(2 * time.Second))
+		if _, err := client.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if !r.Scan() {
+			t.Fatalf("%s: no reply: %v", line, r.Err())
+		}
+		var reply rpcTestReply
+		if err := json.Unmarshal(r.Bytes(), &reply); err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]json.RawMessage
+		json.Unmarshal(r.Bytes(), &fields)
+		_, reply.hasID = fields["id"]
+		return reply
+	}
+}
+
+func TestRPCDispatch(t *testing.T) {
+	call := rpcSession(t, newTestManager(t))
+
+	reply := call(`{"jsonrpc":"2.0","id":1,"method":"list-devices"}`)
+	var states []State
+	if reply.Error != nil || json.Unmarshal(reply.Result, &states) != nil || len(states) != 2 {
+		t.Fatalf("list-devices = %s, %v", reply.Result, reply.Error)
+	}
+	if string(reply.ID) != "1" {
+		t.Errorf("id = %s, want 1", reply.ID)
+	}
+
+	reply = call(`{"jsonrpc":"2.0","id":"a","method":"get-state","params":{"device":"WORK"}}`)
+	var state State
+	if reply.Error != nil || json.Unmarshal(reply.Result, &state) != nil || state.Name != "work" {
+		t.Fatalf("get-state = %s, %v", reply.Result, reply.Error)
+	}
+	if string(reply.ID) != `"a"` {
+		t.Errorf("id = %s, want \"a\"", reply.ID)
+	}
+
+	// Headsets without a name are addressed by MAC.
+	reply = call(`{"jsonrpc":"2.0","id":2,"method":"get-state","params":{"device":"aa:bb:cc:dd:ee:02"}}`)
+	if reply.Error != nil {
+		t.Fatalf("get-state by address: %v", reply.Error)
+	}
+}
+
+func TestRPCErrors(t *testing.T) {
+	call := rpcSession(t, newTestManager(t))
+
+	tests := []struct {
+		name string
+		req  string
+		code int
+		id   string
+	}{
+		{"parse error", `{"jsonrpc":`, CodeParseError, "null"},
+		{"invalid request", `{"jsonrpc":"1.0","id":3,"method":"get-state"}`, CodeInvalidRequest, "3"},
+		{"invalid request without id", `{"jsonrpc":"2.0"}`, CodeInvalidRequest, "null"},
+		{"unknown method", `{"jsonrpc":"2.0","id":4,"method":"reboot"}`, CodeMethodNotFound, "4"},
+		{"bad params", `{"jsonrpc":"2.0","id":5,"method":"get-state","params":[1]}`, CodeInvalidParams, "5"},
+		{"missing device", `{"jsonrpc":"2.0","id":6,"method":"get-state","params":{}}`, CodeInvalidParams, "6"},
+		{"unknown device", `{"jsonrpc":"2.0","id":7,"method":"get-state","params":{"device":"home"}}`, CodeInvalidParams, "7"},
+		{"invalid scene", `{"jsonrpc":"2.0","id":8,"method":"set-anc","params":{"device":"work","scene":"loud"}}`, CodeInvalidParams, "8"},
+		{"unknown profile", `{"jsonrpc":"2.0","id":9,"method":"apply-profile","params":{"device":"work","profile":"loud"}}`, CodeInvalidParams, "9"},
+		{"not connected", `{"jsonrpc":"2.0","id":10,"method":"set-anc","params":{"device":"work","scene":"off"}}`, CodeServerError, "10"},
+	}
+	for _, tt := range tests {
+		reply := call(tt.req)
+		if reply.Error == nil || reply.Error.Code != tt.code {
+			t.Errorf("%s: error = %v, want code %d", tt.name, reply.Error, tt.code)
+		}
+		if !reply.hasID || string(reply.ID) != tt.id {
+			t.Errorf("%s: id = %s (present %v), want %s", tt.name, reply.ID, reply.hasID, tt.id)
+		}
+	}
+}
+
+func TestRPCNotificationHasNoReply(t *testing.T) {
+	call := rpcSession(t, newTestManager(t))
+	// The request without an ID gets no answer, so the next reply is for id 2.
+	reply := call(`{"jsonrpc":"2.0","method":"list-devices"}` + "\n" + `{"jsonrpc":"2.0","id":2,"method":"list-devices"}`)
+	if string(reply.ID) != "2" {
+		t.Fatalf("id = %s, want 2", reply.ID)
+	}
+}
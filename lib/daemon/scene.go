@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+// sceneEnvironments are the environments accepted by name in parseScene.
+var sceneEnvironments = []quicky.ANCEnvironment{
+	quicky.ANCEnvironmentANC,
+	quicky.ANCEnvironmentNormal,
+	quicky.ANCEnvironmentTransparency,
+}
+
+// parseScene parses the format of ANCScene.String: "off", an environment
+// optionally followed by a sub-scene and noise value such as "normal",
+// "anc/2" or "transparency/1/4", or a packed hex value such as "0x010200".
+func parseScene(s string) (quicky.ANCScene, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "off" {
+		return quicky.ANCSceneOff, nil
+	}
+	if hexValue, ok := strings.CutPrefix(s, "0x"); ok {
+		packed, err := strconv.ParseUint(hexValue, 16, 24)
+		if err != nil {
+			return quicky.ANCScene{}, fmt.Errorf("anc scene: invalid packed value %q", s)
+		}
+		return quicky.ANCScene{
+			Mode:       byte(packed >> 16),
+			SubScene:   byte(packed >> 8),
+			NoiseValue: byte(packed),
+		}, nil
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return quicky.ANCScene{}, fmt.Errorf("anc scene: invalid scene %q", s)
+	}
+	var values [2]byte
+	for i, part := range parts[1:] {
+		n, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return quicky.ANCScene{}, fmt.Errorf("anc scene: invalid value %q", part)
+		}
+		values[i] = byte(n)
+	}
+	for _, env := range sceneEnvironments {
+		if env.String() == parts[0] {
+			return quicky.NewANCScene(env, values[0], values[1])
+		}
+	}
+	return quicky.ANCScene{}, fmt.Errorf("anc scene: unknown environment %q", parts[0])
+}
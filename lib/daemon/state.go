@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+// State is the cached state of a headset. Fields the device has not reported
+// yet are nil or empty.
+type State struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	Model     string `json:"model,omitempty"`
+	VendorID  uint16 `json:"vendorId,omitempty"`
+	Connected bool   `json:"connected"`
+	// Error is the last connection error while disconnected.
+	Error string `json:"error,omitempty"`
+
+	Battery *Battery `json:"battery,omitempty"`
+	Version *Version `json:"version,omitempty"`
+	// ANC is the scene in the form of ANCScene.String, e.g. "anc/2", or
+	// the 0x0C noise cancel mode, e.g. "outdoor".
+	ANC            string            `json:"anc,omitempty"`
+	LowLatency     *bool             `json:"lowLatency,omitempty"`
+	EQPreset       *byte             `json:"eqPreset,omitempty"`
+	DualConnection *bool             `json:"dualConnection,omitempty"`
+	Settings       map[string]string `json:"settings,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Battery is the battery state of both earbuds and the case.
+type Battery struct {
+	Left  BatteryLevel `json:"left"`
+	Right BatteryLevel `json:"right"`
+	Case  BatteryLevel `json:"case"`
+}
+
+type BatteryLevel struct {
+	Level    byte `json:"level"`
+	Charging bool `json:"charging"`
+}
+
+func newBattery(b quicky.Battery) *Battery {
+	level := func(i quicky.BatteryInfo) BatteryLevel {
+		return BatteryLevel{Level: i.Level, Charging: i.Charging}
+	}
+	return &Battery{Left: level(b.Left), Right: level(b.Right), Case: level(b.Case)}
+}
+
+// Version is the firmware version of each earbud. Right is empty when the
+// device reports a single version.
+type Version struct {
+	Left  string `json:"left"`
+	Right string `json:"right,omitempty"`
+}
+
+func (s State) clone() State {
+	if s.Settings != nil {
+		settings := make(map[string]string, len(s.Settings))
+		for k, v := range s.Settings {
+			settings[k] = v
+		}
+		s.Settings = settings
+	}
+	return s
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// apply updates the state from a notification and reports whether anything
+// changed. Values of product settings are matched by opcode.
+func (s *State) apply(ev quicky.Event, settings []quicky.ProductSetting) bool {
+	before := s.clone()
+	switch p := ev.Payload.(type) {
+	case quicky.BatteryEvent:
+		s.Battery = newBattery(p.Battery)
+	case quicky.VersionEvent:
+		s.Version = &Version{Left: p.Left, Right: p.Right}
+	case quicky.ANCSettingEvent:
+		// The device reports scenes the way the app decodes them.
+		scene := quicky.ANCScene{Mode: p.Mode, SubScene: p.SubScene, NoiseValue: p.NoiseValue}
+		s.ANC = quicky.UnpackANCScene(scene.Packed()).String()
+	case quicky.NoiseCancelModeEvent:
+		s.ANC = p.Mode.String()
+	case quicky.LowLatencyEvent:
+		s.LowLatency = ptr(p.Enabled)
+	case quicky.EQV1Event:
+		s.EQPreset = ptr(p.EQType)
+	case quicky.EQV2Event:
+		s.EQPreset = ptr(p.EQType)
+	case quicky.DualConnectionEvent:
+		s.DualConnection = ptr(p.Enabled)
+	}
+	if ev.Error == nil && ev.Raw != nil {
+		for _, st := range settings {
+			if st.Kind == quicky.SettingAction || st.Opcode != ev.CmdID {
+				continue
+			}
+			if s.Settings == nil {
+				s.Settings = make(map[string]string)
+			}
+			s.Settings[st.Name] = quicky.SettingState{ProductSetting: st, Raw: ev.Raw}.Value()
+		}
+	}
+	if reflect.DeepEqual(*s, before) {
+		return false
+	}
+	s.UpdatedAt = time.Now()
+	return true
+}
+
+// matches reports whether key names this headset: its configured name or any
+// address it is known by.
+func (s State) matches(key string, cfg HeadsetConfig) bool {
+	key = strings.ToLower(key)
+	return key == strings.ToLower(cfg.Name) ||
+		key == strings.ToLower(cfg.Address) ||
+		key == strings.ToLower(s.Address)
+}
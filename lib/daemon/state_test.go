@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	quicky "github.com/hui1601/Quicky/lib"
+)
+
+func TestStateApply(t *testing.T) {
+	dual := quicky.ProductSetting{Name: "Dual Device Connection", Kind: quicky.SettingToggle, Opcode: 0x24}
+	reset := quicky.ProductSetting{Name: "Reset", Kind: quicky.SettingAction, Opcode: 0x24}
+	settings := []quicky.ProductSetting{dual, reset}
+
+	var s State
+	battery := quicky.Battery{Left: quicky.BatteryInfo{Level: 80, Charging: true}, Right: quicky.BatteryInfo{Level: 70}}
+	if !s.apply(quicky.Event{Payload: quicky.BatteryEvent{Battery: battery}}, settings) {
+		t.Fatal("battery: no change reported")
+	}
+	if s.Battery == nil || s.Battery.Left != (BatteryLevel{80, true}) || s.Battery.Right.Level != 70 {
+		t.Errorf("battery = %+v", s.Battery)
+	}
+	if s.UpdatedAt.IsZero() {
+		t.Error("UpdatedAt not set")
+	}
+	if s.apply(quicky.Event{Payload: quicky.BatteryEvent{Battery: battery}}, settings) {
+		t.Error("battery: repeated value reported as a change")
+	}
+
+	s.apply(quicky.Event{Payload: quicky.ANCSettingEvent{ANCSetting: quicky.ANCSetting{Mode: 0x01, SubScene: 0x02}}}, settings)
+	if s.ANC != "anc/2" {
+		t.Errorf("anc = %q, want anc/2", s.ANC)
+	}
+	// 0x17 scenes are remapped like the app does.
+	s.apply(quicky.Event{Payload: quicky.ANCSettingEvent{ANCSetting: quicky.ANCSetting{Mode: 0x03, SubScene: 0x01}}}, settings)
+	if s.ANC != "transparency/2" {
+		t.Errorf("anc = %q, want transparency/2", s.ANC)
+	}
+	// 0x0C reports a noise cancel mode, not a packed scene.
+	for mode, want := range map[quicky.NoiseCancelMode]string{
+		quicky.NoiseCancelOff:          "off",
+		quicky.NoiseCancelOutdoor:      "outdoor",
+		quicky.NoiseCancelTransparency: "transparency",
+	} {
+		s.apply(quicky.Event{Payload: quicky.NoiseCancelModeEvent{Mode: mode}}, settings)
+		if s.ANC != want {
+			t.Errorf("anc for mode 0x%02x = %q, want %s", byte(mode), s.ANC, want)
+		}
+	}
+
+	ev := quicky.Event{CmdID: 0x24, Raw: []byte{0x01}, Payload: quicky.DualConnectionEvent{DualConnection: quicky.DualConnection{Enabled: true}}}
+	if !s.apply(ev, settings) {
+		t.Fatal("dual connection: no change reported")
+	}
+	if s.DualConnection == nil || !*s.DualConnection {
+		t.Errorf("dualConnection = %v", s.DualConnection)
+	}
+	if got := s.Settings[dual.Name]; got != "on" {
+		t.Errorf("setting %q = %q, want on", dual.Name, got)
+	}
+	if _, ok := s.Settings[reset.Name]; ok {
+		t.Error("action setting recorded as a value")
+	}
+
+	// Failed parses leave the settings alone.
+	before := s.clone()
+	s.apply(quicky.Event{CmdID: 0x24, Raw: []byte{0x02}, Error: errors.New("short frame")}, settings)
+	if s.Settings[dual.Name] != before.Settings[dual.Name] {
+		t.Error("setting changed by an event with an error")
+	}
+}
+
+func TestStateJSON(t *testing.T) {
+	s := State{
+		Name:    "buds",
+		Battery: &Battery{Left: BatteryLevel{Level: 50, Charging: true}},
+		Version: &Version{Left: "1.2.3"},
+		ANC:     "anc/2",
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		`"battery":{"left":{"level":50,"charging":true}`,
+		`"version":{"left":"1.2.3"}`,
+		`"anc":"anc/2"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("state JSON %s does not contain %s", got, want)
+		}
+	}
+}
+
+func TestStateClone(t *testing.T) {
+	s := State{Settings: map[string]string{"a": "on"}}
+	c := s.clone()
+	c.Settings["a"] = "off"
+	if s.Settings["a"] != "on" {
+		t.Error("clone shares the settings map")
+	}
+}
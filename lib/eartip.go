@@ -0,0 +1,74 @@
+package quicky
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hui1601/Quicky/internal/command"
+	"github.com/hui1601/Quicky/internal/response"
+)
+
+const (
+	EarTipFitStatusReady = response.EarTipFitReady
+	EarTipFitStatusDone  = response.EarTipFitResult_
+)
+
+// FitReport holds the raw fit test scores. No good/adjust/poor verdict is
+// given: neither the protocol nor the app documents the scale of the scores
+// or where a fit turns bad, so callers get the numbers as reported.
+type FitReport struct {
+	Left  byte
+	Right byte
+}
+
+func newFitReport(res EarTipFitResult) FitReport {
+	return FitReport{Left: res.LeftResult, Right: res.RightResult}
+}
+
+// RunEarTipFitTest runs a complete ear tip fit test and returns the score
+// for each side. See RunEarTipFitTestWithProgress.
+func (c *Client) RunEarTipFitTest(ctx context.Context) (FitReport, error) {
+	return c.RunEarTipFitTestWithProgress(ctx, nil)
+}
+
+// RunEarTipFitTestWithProgress starts the ear tip fit test (cmd 0x11) and waits
+// until the device reports status 0x02. Every intermediate notification is
+// passed to progress, if set. The stop command is always sent before
+// returning, including when ctx is cancelled.
+func (c *Client) RunEarTipFitTestWithProgress(ctx context.Context, progress func(EarTipFitResult)) (report FitReport, err error) {
+	events, unsubscribe := c.subscribe()
+	defer unsubscribe()
+
+	if err := c.send(command.NewEarTipFitStartCommand()); err != nil {
+		return FitReport{}, err
+	}
+	defer func() {
+		if stopErr := c.send(command.NewEarTipFitStopCommand()); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return FitReport{}, ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return FitReport{}, errors.New("ear tip fit: event stream closed")
+			}
+			if ev.Type != EventEarTipFit || ev.Error != nil {
+				continue
+			}
+			res, ok := ev.Parsed.(EarTipFitResult)
+			if !ok {
+				continue
+			}
+			if progress != nil {
+				progress(res)
+			}
+			if res.Status == EarTipFitStatusDone {
+				return newFitReport(res), nil
+			}
+		}
+	}
+}
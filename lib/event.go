@@ -32,6 +32,7 @@ const (
 	EventEQV1             = response.EventEQV1
 	EventEQV2             = response.EventEQV2
 	EventLDAC             = response.EventLDAC
+	EventDualConnection   = response.EventDualConnection
 	EventAdaptiveEQ       = response.EventAdaptiveEQ
 	EventANCResult        = response.EventANCResult
 	EventANCWear          = response.EventANCWear
@@ -62,20 +63,22 @@ const (
 )
 
 type Event struct {
-	Type   EventType
-	CmdID  byte
-	Raw    []byte
-	Parsed any
-	Error  error
+	Type    EventType
+	CmdID   byte
+	Raw     []byte
+	Parsed  any
+	Payload TypedEvent // typed payload, nil if parsing failed
+	Error   error
 }
 
 func fromInternalEvent(ev response.Event) Event {
 	return Event{
-		Type:   ev.Type,
-		CmdID:  ev.CmdID,
-		Raw:    ev.Raw,
-		Parsed: ev.Parsed,
-		Error:  ev.Error,
+		Type:    ev.Type,
+		CmdID:   ev.CmdID,
+		Raw:     ev.Raw,
+		Parsed:  ev.Parsed,
+		Payload: response.Typed(ev),
+		Error:   ev.Error,
 	}
 }
 
@@ -84,6 +87,7 @@ type PowerManager = response.PowerManager
 type Volume = response.Volume
 type ToneVolume = response.ToneVolume
 type WearingDetection = response.WearingDetection
+type DualConnection = response.DualConnection
 type EarTipFitResult = response.EarTipFitResult
 type ResponseEQBand = response.EQBand
 type EQParams = response.EQParams
@@ -92,3 +96,63 @@ type LEDEffect = response.LEDEffect
 type Alarm = response.Alarm
 type MusicStatus = response.MusicStatus
 type MusicInfoResult = response.MusicInfoResult
+
+type TypedEvent = response.TypedEvent
+
+type (
+	UnknownEvent          = response.UnknownEvent
+	ResetDefaultEvent     = response.ResetDefaultEvent
+	ClearPairingEvent     = response.ClearPairingEvent
+	FactoryResetEvent     = response.FactoryResetEvent
+	MusicControlEvent     = response.MusicControlEvent
+	LightFlashEvent       = response.LightFlashEvent
+	InEarTestEvent        = response.InEarTestEvent
+	NoiseValueEvent       = response.NoiseValueEvent
+	VolumeEvent           = response.VolumeEvent
+	LowLatencyEvent       = response.LowLatencyEvent
+	MonitoringEvent       = response.MonitoringEvent
+	NoiseCancelModeEvent  = response.NoiseCancelModeEvent
+	TestModeEvent         = response.TestModeEvent
+	SleepModeEvent        = response.SleepModeEvent
+	EarTipFitEvent        = response.EarTipFitEvent
+	LEDModeEvent          = response.LEDModeEvent
+	PowerManagerEvent     = response.PowerManagerEvent
+	SoundBalanceEvent     = response.SoundBalanceEvent
+	ANCSettingEvent       = response.ANCSettingEvent
+	RenameEvent           = response.RenameEvent
+	AudioLangEvent        = response.AudioLangEvent
+	ToneVolumeEvent       = response.ToneVolumeEvent
+	TakePhotoEvent        = response.TakePhotoEvent
+	StandbyEvent          = response.StandbyEvent
+	EQV1Event             = response.EQV1Event
+	EQV2Event             = response.EQV2Event
+	LDACEvent             = response.LDACEvent
+	DualConnectionEvent   = response.DualConnectionEvent
+	AdaptiveEQEvent       = response.AdaptiveEQEvent
+	ANCResultEvent        = response.ANCResultEvent
+	ANCWearEvent          = response.ANCWearEvent
+	KeyFunctionEvent      = response.KeyFunctionEvent
+	WearingDetectionEvent = response.WearingDetectionEvent
+	SpatialAudioEvent     = response.SpatialAudioEvent
+	MusicModeEvent        = response.MusicModeEvent
+	BatteryEvent          = response.BatteryEvent
+	VersionEvent          = response.VersionEvent
+	EnvAdaptationEvent    = response.EnvAdaptationEvent
+	TWSEnableEvent        = response.TWSEnableEvent
+	LEDSwitchEvent        = response.LEDSwitchEvent
+	LEDEffectEvent        = response.LEDEffectEvent
+	PlayModeEvent         = response.PlayModeEvent
+	FocusModeEvent        = response.FocusModeEvent
+	MusicStatusEvent      = response.MusicStatusEvent
+	MusicInfoEvent        = response.MusicInfoEvent
+	TonePlayEvent         = response.TonePlayEvent
+	SyncTimeEvent         = response.SyncTimeEvent
+	AlarmEvent            = response.AlarmEvent
+	AIEvent               = response.AIEvent
+	MaxEQCountEvent       = response.MaxEQCountEvent
+	CustomEQTestEvent     = response.CustomEQTestEvent
+	EQLeftEvent           = response.EQLeftEvent
+	EQRightEvent          = response.EQRightEvent
+	InEarSensitivityEvent = response.InEarSensitivityEvent
+	GameConfigEvent       = response.GameConfigEvent
+)
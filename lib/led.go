@@ -0,0 +1,31 @@
+package quicky
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hui1601/Quicky/internal/command"
+	"github.com/hui1601/Quicky/lib/led"
+)
+
+// SetLEDConfig validates and sends an LED effect configuration.
+func (c *Client) SetLEDConfig(cfg led.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	speed, brightness := cfg.Bytes()
+	return c.send(command.NewLEDEffectCommand(speed, brightness, byte(cfg.Effect), cfg.Colors))
+}
+
+// ReadLEDEffect requests the current LED effect (cmd 0x36).
+func (c *Client) ReadLEDEffect(ctx context.Context) (led.Config, error) {
+	ev, err := c.Query(ctx, byte(EventLEDEffect))
+	if err != nil {
+		return led.Config{}, err
+	}
+	e, ok := ev.Parsed.(LEDEffect)
+	if !ok {
+		return led.Config{}, fmt.Errorf("led effect: unexpected payload %T", ev.Parsed)
+	}
+	return led.FromRaw(e.Speed, e.Brightness, e.EffectIndex, e.Colors), nil
+}
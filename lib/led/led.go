@@ -0,0 +1,70 @@
+// Package led provides effect configs and palettes for the LED effect command (0x36)
+// used by QCY speakers and LED charging cases.
+package led
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Effect is the effect index byte of cmd 0x36. Neither the protocol notes nor
+// the product database (products.json has no 0x36 layout) name the indices or
+// give a colour count per index, so names such as breathing or rainbow and
+// per-effect limits are not provided: the index is passed through as is and
+// only the packet size bounds the palette.
+type Effect byte
+
+func (e Effect) String() string {
+	return fmt.Sprintf("effect(%d)", byte(e))
+}
+
+// MaxColors is the largest palette that fits in a single 0x36 packet.
+const MaxColors = (0xff - 3) / 3
+
+// Config is an LED effect with brightness and speed in percent.
+type Config struct {
+	Effect     Effect
+	Speed      int // 0-100
+	Brightness int // 0-100
+	Colors     Palette
+}
+
+func (c Config) Validate() error {
+	if c.Speed < 0 || c.Speed > 100 {
+		return fmt.Errorf("led: speed must be 0-100%%, got %d", c.Speed)
+	}
+	if c.Brightness < 0 || c.Brightness > 100 {
+		return fmt.Errorf("led: brightness must be 0-100%%, got %d", c.Brightness)
+	}
+	if len(c.Colors) > MaxColors {
+		return fmt.Errorf("led: at most %d colors fit in a packet, got %d", MaxColors, len(c.Colors))
+	}
+	return nil
+}
+
+// Bytes returns the speed and brightness bytes for cmd 0x36.
+func (c Config) Bytes() (speed, brightness byte) {
+	return percentToByte(c.Speed), percentToByte(c.Brightness)
+}
+
+// FromRaw converts raw 0x36 fields back into a Config.
+func FromRaw(speed, brightness, effectIndex byte, colors []color.RGBA) Config {
+	return Config{
+		Effect:     Effect(effectIndex),
+		Speed:      byteToPercent(speed),
+		Brightness: byteToPercent(brightness),
+		Colors:     Palette(colors),
+	}
+}
+
+func (c Config) String() string {
+	return fmt.Sprintf("%s speed=%d%% brightness=%d%% colors=%s", c.Effect, c.Speed, c.Brightness, c.Colors)
+}
+
+func percentToByte(p int) byte {
+	return byte((p*0xff + 50) / 100)
+}
+
+func byteToPercent(b byte) int {
+	return (int(b)*100 + 0x7f) / 0xff
+}
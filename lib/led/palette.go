@@ -0,0 +1,175 @@
+package led
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Palette is an ordered list of LED colours.
+type Palette []color.RGBA
+
+// ParseHex parses a comma or space separated list of hex colours such as
+// "ff0000,#00ff00, 00f".
+func ParseHex(list string) (Palette, error) {
+	fields := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	p := make(Palette, 0, len(fields))
+	for _, f := range fields {
+		c, err := ParseHexColor(f)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, c)
+	}
+	return p, nil
+}
+
+// ParseHexColor parses "#rrggbb", "rrggbb" or the short "#rgb" form.
+func ParseHexColor(s string) (color.RGBA, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 {
+		return color.RGBA{}, fmt.Errorf("led: invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("led: invalid color %q", s)
+	}
+	return color.RGBA{R: byte(v >> 16), G: byte(v >> 8), B: byte(v), A: 0xff}, nil
+}
+
+func (p Palette) String() string {
+	parts := make([]string, len(p))
+	for i, c := range p {
+		parts[i] = fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return strings.Join(parts, ",")
+}
+
+// RainbowPalette returns n colours evenly spaced around the hue circle.
+func RainbowPalette(n int) Palette {
+	if n <= 0 {
+		return nil
+	}
+	p := make(Palette, n)
+	for i := range p {
+		p[i] = hue(float64(i) / float64(n))
+	}
+	return p
+}
+
+// Gradient returns n colours linearly interpolated from a to b.
+func Gradient(a, b color.RGBA, n int) Palette {
+	if n <= 0 {
+		return nil
+	}
+	if n == 1 {
+		return Palette{a}
+	}
+	p := make(Palette, n)
+	for i := range p {
+		t := float64(i) / float64(n-1)
+		p[i] = color.RGBA{
+			R: lerp(a.R, b.R, t),
+			G: lerp(a.G, b.G, t),
+			B: lerp(a.B, b.B, t),
+			A: 0xff,
+		}
+	}
+	return p
+}
+
+// FromImage returns the n most common colours of img. Pixels are bucketed to
+// 4 bits per channel and each bucket is represented by its average colour.
+func FromImage(img image.Image, n int) Palette {
+	if n <= 0 {
+		return nil
+	}
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[uint16]*bucket)
+	bounds := img.Bounds()
+	// Sample at most ~64k pixels.
+	step := 1
+	for (bounds.Dx()/step)*(bounds.Dy()/step) > 1<<16 {
+		step++
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if c.A < 0x80 {
+				continue
+			}
+			key := uint16(c.R>>4)<<8 | uint16(c.G>>4)<<4 | uint16(c.B>>4)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += int(c.R)
+			bk.g += int(c.G)
+			bk.b += int(c.B)
+		}
+	}
+
+	keys := make([]uint16, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if buckets[keys[i]].count != buckets[keys[j]].count {
+			return buckets[keys[i]].count > buckets[keys[j]].count
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	p := make(Palette, len(keys))
+	for i, k := range keys {
+		bk := buckets[k]
+		p[i] = color.RGBA{
+			R: byte(bk.r / bk.count),
+			G: byte(bk.g / bk.count),
+			B: byte(bk.b / bk.count),
+			A: 0xff,
+		}
+	}
+	return p
+}
+
+func lerp(a, b byte, t float64) byte {
+	return byte(float64(a) + (float64(b)-float64(a))*t + 0.5)
+}
+
+// hue converts a hue in [0, 1) at full saturation and value to RGB.
+func hue(h float64) color.RGBA {
+	h *= 6
+	i := int(h)
+	f := h - float64(i)
+	q := byte(255 * (1 - f))
+	t := byte(255 * f)
+	switch i % 6 {
+	case 0:
+		return color.RGBA{R: 255, G: t, A: 0xff}
+	case 1:
+		return color.RGBA{R: q, G: 255, A: 0xff}
+	case 2:
+		return color.RGBA{G: 255, B: t, A: 0xff}
+	case 3:
+		return color.RGBA{G: q, B: 255, A: 0xff}
+	case 4:
+		return color.RGBA{R: t, B: 255, A: 0xff}
+	}
+	return color.RGBA{R: 255, B: q, A: 0xff}
+}
@@ -0,0 +1,35 @@
+package quicky
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hui1601/Quicky/internal/command"
+)
+
+// SetDualConnection enables or disables multipoint, letting the headset stay
+// connected to two hosts (cmd 0x24).
+func (c *Client) SetDualConnection(on bool) error {
+	return c.send(command.NewDualConnectionCommand(on))
+}
+
+// DualConnection reads the multipoint state.
+func (c *Client) DualConnection(ctx context.Context) (DualConnection, error) {
+	ev, err := c.Query(ctx, byte(EventDualConnection))
+	if err != nil {
+		return DualConnection{}, err
+	}
+	dc, ok := ev.Parsed.(DualConnection)
+	if !ok {
+		return DualConnection{}, fmt.Errorf("dual connection: unexpected payload %T", ev.Parsed)
+	}
+	return dc, nil
+}
+
+// OnDualConnection calls fn whenever the device reports a multipoint change,
+// e.g. after it was toggled from the phone app. Call the returned function to stop.
+func (c *Client) OnDualConnection(fn func(DualConnection)) func() {
+	return On(c, func(ev DualConnectionEvent) {
+		fn(ev.DualConnection)
+	})
+}
@@ -0,0 +1,51 @@
+package quicky
+
+import (
+	"context"
+	"errors"
+)
+
+// On calls fn for every notification whose typed payload is a T, for example
+//
+//	stop := quicky.On(client, func(ev quicky.BatteryEvent) { ... })
+//
+// Callbacks run on a single goroutine. The returned function stops delivery
+// and waits for a callback in progress to return, so fn must not call it
+// directly; use "go stop()" to stop from within fn.
+func On[T TypedEvent](c *Client, fn func(T)) func() {
+	events, unsubscribe := c.subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range events {
+			if v, ok := ev.Payload.(T); ok {
+				fn(v)
+			}
+		}
+	}()
+	return func() {
+		unsubscribe()
+		<-done
+	}
+}
+
+// WaitFor returns the next notification whose typed payload is a T.
+func WaitFor[T TypedEvent](ctx context.Context, c *Client) (T, error) {
+	events, unsubscribe := c.subscribe()
+	defer unsubscribe()
+
+	var zero T
+	for {
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return zero, errors.New("event stream closed")
+			}
+			if v, ok := ev.Payload.(T); ok {
+				return v, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package quicky
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// PassiveBatteryUpdate is a battery change decoded from advertisements only.
+type PassiveBatteryUpdate struct {
+	ControlMAC string
+	Address    bluetooth.Address
+	RSSI       int16
+	Battery    Battery
+	// CaseOpen reports whether the advertisement came from an open case:
+	// the case level is only included while the lid is open.
+	CaseOpen bool
+	Time     time.Time
+}
+
+// passiveTracker merges advertisements per control MAC and reports changes.
+type passiveTracker struct {
+	mu    sync.Mutex
+	known map[string]bool
+	last  map[string]PassiveBatteryUpdate
+}
+
+func newPassiveTracker(known []string) *passiveTracker {
+	t := &passiveTracker{last: make(map[string]PassiveBatteryUpdate)}
+	if len(known) > 0 {
+		t.known = make(map[string]bool, len(known))
+		for _, mac := range known {
+			t.known[strings.ToLower(mac)] = true
+		}
+	}
+	return t
+}
+
+// mergeSide keeps the previous reading for sides that are not reported. A
+// bud in a closed case, or the case itself while closed, advertises level 0
+// without the charging bit.
+func mergeSide(prev, cur BatteryInfo) BatteryInfo {
+	if cur.Level == 0 && !cur.Charging {
+		return prev
+	}
+	return cur
+}
+
+func (t *passiveTracker) update(r ScanResult, now time.Time) (PassiveBatteryUpdate, bool) {
+	adv := r.Advertisement
+	if adv == nil {
+		return PassiveBatteryUpdate{}, false
+	}
+	// The control MAC field is all zeros until the headset broadcasts it, and
+	// unrelated headsets would share that key.
+	if mac, err := bluetooth.ParseMAC(adv.ControlMAC); err != nil || mac == (bluetooth.MAC{}) {
+		return PassiveBatteryUpdate{}, false
+	}
+	key := strings.ToLower(adv.ControlMAC)
+	if t.known != nil && !t.known[key] {
+		return PassiveBatteryUpdate{}, false
+	}
+
+	cur := Battery{
+		Left:  BatteryInfo{Level: adv.LeftBattery, Charging: adv.IsLeftCharging},
+		Right: BatteryInfo{Level: adv.RightBattery, Charging: adv.IsRightCharging},
+		Case:  BatteryInfo{Level: adv.BoxBattery, Charging: adv.IsBoxCharging},
+	}
+	caseOpen := adv.BoxBattery != 0 || adv.IsBoxCharging
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev, seen := t.last[key]
+	if seen {
+		cur.Left = mergeSide(prev.Battery.Left, cur.Left)
+		cur.Right = mergeSide(prev.Battery.Right, cur.Right)
+		cur.Case = mergeSide(prev.Battery.Case, cur.Case)
+	}
+	u := PassiveBatteryUpdate{
+		ControlMAC: key,
+		Address:    r.Address,
+		RSSI:       r.RSSI,
+		Battery:    cur,
+		CaseOpen:   caseOpen,
+		Time:       now,
+	}
+	t.last[key] = u
+	// Both buds and the control address advertise; only report real changes.
+	changed := !seen || prev.Battery != cur || prev.CaseOpen != caseOpen
+	return u, changed
+}
+
+// passiveStopRetry is how often MonitorBattery retries stopping a scan that
+// had not started yet when ctx was done.
+const passiveStopRetry = 50 * time.Millisecond
+
+// MonitorBattery scans passively and calls fn whenever the battery state of a
+// headset changes. It never connects, so the buds stay free for the phone.
+// Advertisements are deduplicated by control MAC; if known is non-empty only
+// those control MACs are reported. It returns when ctx is done.
+//
+// Updates can be fed into a BatteryMonitor created with a nil client via Record.
+func (s *Scanner) MonitorBattery(ctx context.Context, known []string, fn func(PassiveBatteryUpdate)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tracker := newPassiveTracker(known)
+
+	// ctx can be done before ScanFunc has started the scan, when StopScan
+	// still fails, so keep stopping until the scan has returned.
+	scanDone := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		for s.StopScan() != nil {
+			select {
+			case <-scanDone:
+				return
+			case <-time.After(passiveStopRetry):
+			}
+		}
+	})
+	defer stop()
+
+	err := s.ScanFunc(func(r ScanResult) {
+		if ctx.Err() != nil {
+			return
+		}
+		if u, changed := tracker.update(r, time.Now()); changed {
+			fn(u)
+		}
+	})
+	close(scanDone)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
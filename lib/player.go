@@ -0,0 +1,215 @@
+package quicky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Track is a music file stored on the device (cmd 0x3B).
+type Track struct {
+	ID    uint32
+	Total uint16
+}
+
+// Player controls the music library stored on models that keep tones or music
+// on the device. It follows 0x3A and 0x3B notifications to know the current
+// track, play mode and track list.
+type Player struct {
+	c *Client
+
+	mu        sync.Mutex
+	status    MusicStatus
+	hasStatus bool
+	tracks    []Track
+	startTone byte
+
+	stop func()
+	done chan struct{}
+}
+
+// Player returns a player for the device's music library. Close it when done.
+func (c *Client) Player() *Player {
+	events, unsubscribe := c.subscribe()
+	p := &Player{c: c, stop: unsubscribe, done: make(chan struct{})}
+	go p.watch(events)
+	return p
+}
+
+func (p *Player) watch(events <-chan Event) {
+	defer close(p.done)
+	for ev := range events {
+		if ev.Error != nil {
+			continue
+		}
+		switch v := ev.Parsed.(type) {
+		case MusicStatus:
+			p.setStatus(v)
+		case MusicInfoResult:
+			p.setTracks(v)
+		}
+	}
+}
+
+func (p *Player) setStatus(s MusicStatus) {
+	p.mu.Lock()
+	p.status, p.hasStatus = s, true
+	p.mu.Unlock()
+}
+
+func (p *Player) setTracks(info MusicInfoResult) {
+	tracks := make([]Track, len(info.Files))
+	for i, f := range info.Files {
+		tracks[i] = Track{ID: f.MusicID, Total: f.Total}
+	}
+	p.mu.Lock()
+	p.tracks, p.startTone = tracks, info.StartToneID
+	p.mu.Unlock()
+}
+
+// Close stops following notifications.
+func (p *Player) Close() {
+	p.stop()
+	<-p.done
+}
+
+// Tracks reads the track list from the device.
+func (p *Player) Tracks(ctx context.Context) ([]Track, error) {
+	ev, err := p.c.Query(ctx, byte(EventMusicInfo))
+	if err != nil {
+		return nil, err
+	}
+	info, ok := ev.Parsed.(MusicInfoResult)
+	if !ok {
+		return nil, fmt.Errorf("music info: unexpected payload %T", ev.Parsed)
+	}
+	p.setTracks(info)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Track(nil), p.tracks...), nil
+}
+
+// Status reads the current playback status from the device.
+func (p *Player) Status(ctx context.Context) (MusicStatus, error) {
+	ev, err := p.c.Query(ctx, byte(EventMusicStatus))
+	if err != nil {
+		return MusicStatus{}, err
+	}
+	s, ok := ev.Parsed.(MusicStatus)
+	if !ok {
+		return MusicStatus{}, fmt.Errorf("music status: unexpected payload %T", ev.Parsed)
+	}
+	p.setStatus(s)
+	return s, nil
+}
+
+// Current returns the last known playback status.
+func (p *Player) Current() (MusicStatus, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status, p.hasStatus
+}
+
+func (p *Player) sendStatus(s MusicStatus) error {
+	if err := p.c.SetMusicStatus(s.MusicID, s.IsPlaying, s.PlayMode); err != nil {
+		return err
+	}
+	p.setStatus(s)
+	return nil
+}
+
+// Play starts playing the track with the given ID.
+func (p *Player) Play(id uint32) error {
+	s, _ := p.Current()
+	s.MusicID, s.IsPlaying = id, true
+	return p.sendStatus(s)
+}
+
+// Resume continues the current track.
+func (p *Player) Resume() error {
+	s, ok := p.Current()
+	if !ok {
+		return errors.New("player: no current track")
+	}
+	s.IsPlaying = true
+	return p.sendStatus(s)
+}
+
+// Pause pauses the current track.
+func (p *Player) Pause() error {
+	s, ok := p.Current()
+	if !ok {
+		return errors.New("player: no current track")
+	}
+	s.IsPlaying = false
+	return p.sendStatus(s)
+}
+
+// Next plays the track after the current one, wrapping at the end of the list.
+func (p *Player) Next() error {
+	return p.skip(1)
+}
+
+// Previous plays the track before the current one, wrapping at the start of the list.
+func (p *Player) Previous() error {
+	return p.skip(-1)
+}
+
+func (p *Player) skip(delta int) error {
+	p.mu.Lock()
+	tracks, s := p.tracks, p.status
+	p.mu.Unlock()
+	if len(tracks) == 0 {
+		return errors.New("player: track list unknown, call Tracks first")
+	}
+	next := 0
+	for i, t := range tracks {
+		if t.ID == s.MusicID {
+			next = (i + delta + len(tracks)) % len(tracks)
+			break
+		}
+	}
+	return p.Play(tracks[next].ID)
+}
+
+// SetMode changes the playback order.
+func (p *Player) SetMode(mode PlayMode) error {
+	if err := p.c.SetPlayMode(mode); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.status.PlayMode = mode
+	p.mu.Unlock()
+	return nil
+}
+
+// NowPlaying streams playback status notifications (0x3A) until ctx is done.
+func (p *Player) NowPlaying(ctx context.Context) <-chan MusicStatus {
+	events, unsubscribe := p.c.subscribe()
+	ch := make(chan MusicStatus, 8)
+	go func() {
+		defer close(ch)
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				s, isStatus := ev.Parsed.(MusicStatus)
+				if !isStatus || ev.Error != nil {
+					continue
+				}
+				select {
+				case ch <- s:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,59 @@
+package quicky
+
+import (
+	"errors"
+
+	"github.com/hui1601/Quicky/internal/product"
+)
+
+type ProductOverlay = product.Overlay
+
+// LoadProductOverlay reads an overlay file that adds or corrects product
+// database entries. Apply it with MergeProducts.
+func LoadProductOverlay(path string) (ProductOverlay, error) {
+	return product.Load(path)
+}
+
+// MergeProducts applies overlays to the product database. Schema errors and
+// two overlays changing the same vendor ID are reported as errors and leave
+// the database unchanged.
+func MergeProducts(overlays ...ProductOverlay) error {
+	return product.Merge(overlays...)
+}
+
+// LoadUserProductOverlays merges ~/.config/quicky/products.d/*.json into the
+// product database. It also reports if the embedded database failed to
+// load, in which case only the overlays are available.
+func LoadUserProductOverlays() error {
+	return errors.Join(product.EmbeddedError(), product.LoadUserOverlays())
+}
+
+const (
+	ProductCategoryEarphones = product.CategoryEarphones
+	ProductCategoryWatches   = product.CategoryWatches
+	ProductCategoryAccessory = product.CategoryAccessory
+	ProductCategorySpeaker   = product.CategorySpeaker
+)
+
+// Products returns every known model, ordered by vendor ID.
+func Products() []Product {
+	return product.All()
+}
+
+// SearchProducts matches query against model titles, subtitles and aliases,
+// best match first.
+func SearchProducts(query string) []Product {
+	return product.Search(query)
+}
+
+// FilterProducts returns the models fn accepts, e.g.
+// func(p Product) bool { return p.HasANC() && p.EQBands() >= 10 }.
+func FilterProducts(fn func(Product) bool) []Product {
+	return product.Filter(fn)
+}
+
+// ProductsByCategory returns the models of a category such as
+// ProductCategoryEarphones.
+func ProductsByCategory(category string) []Product {
+	return product.ByCategory(category)
+}
@@ -2,28 +2,124 @@ package quicky
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"image/color"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hui1601/Quicky/internal/command"
 	"github.com/hui1601/Quicky/internal/device"
+	"github.com/hui1601/Quicky/internal/discovery"
 	"github.com/hui1601/Quicky/internal/response"
+	"tinygo.org/x/bluetooth"
 )
 
 type Client struct {
-	dev *device.Client
+	dev      *device.Client
+	product  *Product
+	addr     string
+	resolved atomic.Bool
+
+	pump    sync.Once
+	subMu   sync.Mutex
+	subs    map[*subscriber]struct{}
+	dropped atomic.Uint64
+
+	hookMu       sync.Mutex
+	connectHooks map[int]func()
+	nextHook     int
 }
 
+// New creates a client for a headset. mac may be the BLE address of either
+// bud, the control MAC or the other bud's MAC; Connect resolves it to the
+// control MAC.
 func New(mac string) (*Client, error) {
 	dev, err := device.NewClient(mac)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{dev: dev}, nil
+	return &Client{dev: dev, addr: mac}, nil
+}
+
+// Resolve scans until the headset advertising the address given to New is
+// found, or ctx is done, and makes Connect use its control MAC. The product
+// is also set from the advertised vendor ID, unless SetProduct was called.
+func (c *Client) Resolve(ctx context.Context) (Headset, error) {
+	h, err := discovery.NewScanner(c.dev.Adapter).FindHeadset(ctx, c.addr)
+	if err != nil {
+		return Headset{}, err
+	}
+	mac, err := bluetooth.ParseMAC(h.ControlMAC)
+	if err != nil {
+		return Headset{}, fmt.Errorf("resolve %s: %w", c.addr, err)
+	}
+	if mac == (bluetooth.MAC{}) {
+		return Headset{}, fmt.Errorf("resolve %s: control MAC not broadcast yet", c.addr)
+	}
+	c.dev.MAC = mac
+	c.resolved.Store(true)
+	if c.product == nil {
+		if p, ok := h.Product(); ok {
+			c.product = p
+		}
+	}
+	return h, nil
 }
 
+// Address returns the address Connect uses, which is the control MAC once resolved.
+func (c *Client) Address() string {
+	return c.dev.MAC.String()
+}
+
+// resolveTimeout bounds the scan Connect runs for the control MAC.
+const resolveTimeout = 5 * time.Second
+
+// Connect connects to the headset. Until Resolve has succeeded it first scans
+// for the control MAC for up to resolveTimeout; a headset that is not
+// advertising, for example because it is connected to a phone, is tried at
+// the address given to New.
 func (c *Client) Connect(ctx context.Context) error {
-	return c.dev.Connect(ctx)
+	if !c.resolved.Load() {
+		resolveCtx, cancel := context.WithTimeout(ctx, resolveTimeout)
+		_, err := c.Resolve(resolveCtx)
+		cancel()
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	if err := c.dev.Connect(ctx); err != nil {
+		return err
+	}
+	c.hookMu.Lock()
+	hooks := make([]func(), 0, len(c.connectHooks))
+	for _, h := range c.connectHooks {
+		hooks = append(hooks, h)
+	}
+	c.hookMu.Unlock()
+	for _, h := range hooks {
+		h()
+	}
+	return nil
+}
+
+// onConnect registers fn to run after every successful Connect. fn must not
+// block. The returned function removes the hook.
+func (c *Client) onConnect(fn func()) func() {
+	c.hookMu.Lock()
+	defer c.hookMu.Unlock()
+	if c.connectHooks == nil {
+		c.connectHooks = make(map[int]func())
+	}
+	id := c.nextHook
+	c.nextHook++
+	c.connectHooks[id] = fn
+	return func() {
+		c.hookMu.Lock()
+		delete(c.connectHooks, id)
+		c.hookMu.Unlock()
+	}
 }
 
 func (c *Client) Disconnect() error {
@@ -34,17 +130,108 @@ func (c *Client) Connected() bool {
 	return c.dev.Connected()
 }
 
+// subscriberBuffer is the number of events held for a subscriber that has
+// not read them yet.
+const subscriberBuffer = 64
+
+// Events returns a new channel receiving the notifications from the device.
+// Each call creates an independent subscriber with its own buffer. Delivery
+// never waits for a subscriber: once its buffer is full, further events for
+// it are dropped and counted in DroppedEvents. The device layer also drops
+// notifications if they are not taken from it fast enough.
 func (c *Client) Events() <-chan Event {
-	ch := make(chan Event, 32)
-	go func() {
-		for ev := range c.dev.Events() {
-			ch <- fromInternalEvent(ev)
-		}
-		close(ch)
-	}()
+	ch, _ := c.subscribe()
 	return ch
 }
 
+// Subscribe is like Events, but also returns a function that removes the
+// subscriber and closes its channel. Long-lived consumers should use it so
+// subscribers do not pile up.
+func (c *Client) Subscribe() (<-chan Event, func()) {
+	return c.subscribe()
+}
+
+// DroppedEvents returns the number of events dropped for subscribers whose
+// buffer was full.
+func (c *Client) DroppedEvents() uint64 {
+	return c.dropped.Load()
+}
+
+type subscriber struct {
+	ch     chan Event
+	mu     sync.Mutex // held while sending, so ch is not closed mid-send
+	closed bool
+}
+
+// send delivers e without blocking and reports whether it was dropped.
+func (s *subscriber) send(e Event) (dropped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.ch <- e:
+		return false
+	default:
+		return true
+	}
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// subscribe registers a new event subscriber. The returned function removes
+// the subscriber and closes its channel.
+func (c *Client) subscribe() (<-chan Event, func()) {
+	c.pump.Do(func() { go c.fanOut() })
+
+	sub := &subscriber{ch: make(chan Event, subscriberBuffer)}
+	c.subMu.Lock()
+	if c.subs == nil {
+		c.subs = make(map[*subscriber]struct{})
+	}
+	c.subs[sub] = struct{}{}
+	c.subMu.Unlock()
+
+	return sub.ch, func() {
+		c.subMu.Lock()
+		delete(c.subs, sub)
+		c.subMu.Unlock()
+		sub.close()
+	}
+}
+
+func (c *Client) fanOut() {
+	for ev := range c.dev.Events() {
+		e := fromInternalEvent(ev)
+		c.subMu.Lock()
+		subs := make([]*subscriber, 0, len(c.subs))
+		for sub := range c.subs {
+			subs = append(subs, sub)
+		}
+		c.subMu.Unlock()
+		for _, sub := range subs {
+			if sub.send(e) {
+				c.dropped.Add(1)
+			}
+		}
+	}
+	c.subMu.Lock()
+	subs := c.subs
+	c.subs = nil
+	c.subMu.Unlock()
+	for sub := range subs {
+		sub.close()
+	}
+}
+
 func (c *Client) send(cmd *command.Command) error {
 	return c.dev.SendCommand(cmd)
 }
@@ -63,7 +250,21 @@ func (c *Client) FactoryReset() error {
 
 type MusicAction = command.MusicControlAction
 
+const (
+	MusicPlay     = command.MusicPlay
+	MusicPause    = command.MusicPause
+	MusicPrevious = command.MusicPrevious
+	MusicNext     = command.MusicNext
+)
+
+func ParseMusicAction(s string) (MusicAction, error) {
+	return command.ParseMusicControlAction(s)
+}
+
 func (c *Client) MusicControl(action MusicAction) error {
+	if err := action.Validate(); err != nil {
+		return err
+	}
 	return c.send(command.NewMusicControlCommand(action))
 }
 
@@ -152,8 +353,8 @@ func (c *Client) TakePhoto(action byte) error {
 	return c.send(command.NewTakePhotoCommand(action))
 }
 
-func (c *Client) SetStandby(state byte) error {
-	return c.send(command.NewStandbyCommand(state))
+func (c *Client) SetStandby(on bool) error {
+	return c.send(command.NewStandbyCommand(on))
 }
 
 func (c *Client) SetLDAC(on bool) error {
@@ -180,8 +381,8 @@ func (c *Client) SetMusicMode(mode byte) error {
 	return c.send(command.NewMusicModeCommand(mode))
 }
 
-func (c *Client) SetEnvAdaptation(state byte) error {
-	return c.send(command.NewEnvAdaptationCommand(state))
+func (c *Client) SetEnvAdaptation(on bool) error {
+	return c.send(command.NewEnvAdaptationCommand(on))
 }
 
 func (c *Client) SetTWSEnable(on bool) error {
@@ -196,7 +397,21 @@ func (c *Client) SetLEDEffect(speed, brightness, effectIndex byte, colors []colo
 	return c.send(command.NewLEDEffectCommand(speed, brightness, effectIndex, colors))
 }
 
-func (c *Client) SetPlayMode(mode byte) error {
+type PlayMode = command.PlayMode
+
+const (
+	PlayModeShuffle   = command.PlayModeShuffle
+	PlayModeRepeatOne = command.PlayModeRepeatOne
+)
+
+func ParsePlayMode(s string) (PlayMode, error) {
+	return command.ParsePlayMode(s)
+}
+
+func (c *Client) SetPlayMode(mode PlayMode) error {
+	if err := mode.Validate(); err != nil {
+		return err
+	}
 	return c.send(command.NewPlayModeCommand(mode))
 }
 
@@ -204,7 +419,7 @@ func (c *Client) SetFocusMode(on bool) error {
 	return c.send(command.NewFocusModeCommand(on))
 }
 
-func (c *Client) SetMusicStatus(musicID uint32, isPlaying bool, playMode byte) error {
+func (c *Client) SetMusicStatus(musicID uint32, isPlaying bool, playMode PlayMode) error {
 	return c.send(command.NewMusicStatusCommand(musicID, isPlaying, playMode))
 }
 
@@ -261,6 +476,34 @@ func (c *Client) RequestData(cmdID byte) error {
 	return c.send(command.NewRequestDataCommand(cmdID))
 }
 
+// Query requests the current value of cmdID and waits for the matching notification.
+func (c *Client) Query(ctx context.Context, cmdID byte) (Event, error) {
+	return c.sendAndWait(ctx, command.NewRequestDataCommand(cmdID), cmdID)
+}
+
+// sendAndWait sends cmd and returns the first notification carrying cmdID.
+func (c *Client) sendAndWait(ctx context.Context, cmd *command.Command, cmdID byte) (Event, error) {
+	events, unsubscribe := c.subscribe()
+	defer unsubscribe()
+
+	if err := c.send(cmd); err != nil {
+		return Event{}, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return Event{}, errors.New("event stream closed")
+			}
+			if ev.CmdID == cmdID {
+				return ev, ev.Error
+			}
+		}
+	}
+}
+
 type EQBand struct {
 	Freq     uint16
 	Gain     int16
@@ -1,6 +1,8 @@
 package quicky
 
 import (
+	"context"
+
 	"github.com/hui1601/Quicky/internal/discovery"
 	"github.com/hui1601/Quicky/internal/product"
 	"github.com/hui1601/Quicky/internal/utils"
@@ -8,20 +10,33 @@ import (
 )
 
 type AdvertisementInfo = utils.AdvertisementInfo
+type WatchAdvertisement = utils.WatchAdvertisement
 type Product = product.Product
 
+// ScanResult is one QCY advertisement. Earphones fill Advertisement, watches
+// (CompanyID 0x05D6, only reported by ScanAllFunc) fill Watch.
 type ScanResult struct {
 	Address       bluetooth.Address
 	RSSI          int16
 	Name          string
 	Advertisement *AdvertisementInfo
+	Watch         *WatchAdvertisement
+}
+
+// IsWatch reports whether the advertisement came from a QCY watch.
+func (r ScanResult) IsWatch() bool {
+	return r.Watch != nil
 }
 
+// GetProductInfo looks up the product definition of an earphone or watch.
 func (r ScanResult) GetProductInfo() (*Product, bool) {
-	if r.Advertisement == nil {
-		return nil, false
+	switch {
+	case r.Advertisement != nil:
+		return product.Lookup(r.Advertisement.VendorID)
+	case r.Watch != nil:
+		return product.Lookup(r.Watch.VendorID)
 	}
-	return product.Lookup(r.Advertisement.VendorID)
+	return nil, false
 }
 
 type Scanner struct {
@@ -36,17 +51,72 @@ func NewScannerWithAdapter(adapter *bluetooth.Adapter) *Scanner {
 	return &Scanner{s: discovery.NewScanner(adapter)}
 }
 
-func (s *Scanner) Scan(callback func(ScanResult)) error {
+type ScanOptions = discovery.ScanOptions
+type Registry = discovery.Registry
+type RegistryEvent = discovery.RegistryEvent
+type RegistryEventType = discovery.RegistryEventType
+type DiscoveredDevice = discovery.Device
+type Headset = discovery.Headset
+type HeadsetSource = discovery.HeadsetSource
+type AddressRole = discovery.AddressRole
+
+const (
+	DeviceAppeared = discovery.DeviceAppeared
+	DeviceUpdated  = discovery.DeviceUpdated
+	DeviceLost     = discovery.DeviceLost
+
+	RoleBud     = discovery.RoleBud
+	RoleControl = discovery.RoleControl
+)
+
+// Scan starts a background scan and returns the live registry of matching
+// devices. The scan ends when ctx is done or opts.Duration elapses.
+func (s *Scanner) Scan(ctx context.Context, opts ScanOptions) (*Registry, error) {
+	return s.s.ScanRegistry(ctx, opts)
+}
+
+// ScanFunc calls callback for every QCY earphone advertisement until
+// StopScan is called. Advertisement is always set.
+func (s *Scanner) ScanFunc(callback func(ScanResult)) error {
 	return s.s.Scan(func(result discovery.ScanResult) {
-		callback(ScanResult{
-			Address:       result.Address,
-			RSSI:          result.RSSI,
-			Name:          result.Name,
-			Advertisement: result.Advertisement,
-		})
+		callback(newScanResult(result))
+	})
+}
+
+// ScanAllFunc is like ScanFunc but also reports QCY watches, whose results
+// have Watch set and a nil Advertisement.
+func (s *Scanner) ScanAllFunc(callback func(ScanResult)) error {
+	return s.s.ScanAll(func(result discovery.ScanResult) {
+		callback(newScanResult(result))
 	})
 }
 
+func newScanResult(result discovery.ScanResult) ScanResult {
+	return ScanResult{
+		Address:       result.Address,
+		RSSI:          result.RSSI,
+		Name:          result.Name,
+		Advertisement: result.Advertisement,
+		Watch:         result.Watch,
+	}
+}
+
 func (s *Scanner) StopScan() error {
 	return s.s.StopScan()
 }
+
+// FindHeadset scans until the headset that addr belongs to is found. addr may
+// be the BLE address of either bud, the control MAC or the other bud's MAC.
+func (s *Scanner) FindHeadset(ctx context.Context, addr string) (Headset, error) {
+	return s.s.FindHeadset(ctx, addr)
+}
+
+var ErrControlMACTimeout = discovery.ErrControlMACTimeout
+
+// ResolveControlMAC connects to and disconnects from the bud advertising as
+// bleAddr, then rescans until its control MAC is broadcast. It fails with
+// ErrControlMACTimeout if the control MAC does not appear before ctx is done
+// (15 seconds if ctx has no deadline).
+func (s *Scanner) ResolveControlMAC(ctx context.Context, bleAddr string) (string, error) {
+	return s.s.ResolveControlMAC(ctx, bleAddr)
+}
@@ -0,0 +1,211 @@
+package quicky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hui1601/Quicky/internal/command"
+	"github.com/hui1601/Quicky/internal/product"
+)
+
+type ProductSetting = product.Setting
+type SettingKind = product.SettingKind
+
+const (
+	SettingAction = product.SettingAction
+	SettingToggle = product.SettingToggle
+	SettingText   = product.SettingText
+	SettingSlider = product.SettingSlider
+)
+
+var ErrNoProduct = errors.New("product unknown: call Resolve or SetProduct")
+
+// ProductSettings returns the settings the product's control panel lists,
+// normalised to a kind and the opcode they drive.
+func (c *Client) ProductSettings() ([]ProductSetting, error) {
+	if c.product == nil {
+		return nil, ErrNoProduct
+	}
+	return c.product.Settings(), nil
+}
+
+// ProductSetting returns the setting with the given name, ignoring case.
+func (c *Client) ProductSetting(name string) (ProductSetting, error) {
+	if c.product == nil {
+		return ProductSetting{}, ErrNoProduct
+	}
+	s, ok := c.product.Setting(name)
+	if !ok {
+		return ProductSetting{}, fmt.Errorf("setting %q: not listed for %s", name, c.product.Title)
+	}
+	return s, nil
+}
+
+// settingQueryTimeout bounds the read-back of one setting in Settings.
+const settingQueryTimeout = 2 * time.Second
+
+// SettingState is a setting with its current value on the device.
+type SettingState struct {
+	ProductSetting
+	// Raw is the parameter bytes the device reported, nil if unknown.
+	Raw []byte
+}
+
+// Known reports whether the device reported a value.
+func (s SettingState) Known() bool {
+	return s.Raw != nil
+}
+
+// Value formats the current value the way SetSetting accepts it: "on" or
+// "off", text or a number. It is empty if the value is unknown.
+func (s SettingState) Value() string {
+	if len(s.Raw) == 0 {
+		return ""
+	}
+	v := s.Raw[0]
+	switch s.Kind {
+	case SettingToggle:
+		switch v {
+		case 0x01:
+			return "on"
+		case 0x00, 0x02:
+			return "off"
+		}
+	case SettingText:
+		return string(s.Raw)
+	}
+	return strconv.Itoa(int(v))
+}
+
+// Settings returns the product's settings with their current values, read
+// with RequestData (0xFE). Actions and settings the device does not answer
+// within two seconds are returned without a value.
+func (c *Client) Settings(ctx context.Context) ([]SettingState, error) {
+	settings, err := c.ProductSettings()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[byte][]byte)
+	out := make([]SettingState, len(settings))
+	for i, s := range settings {
+		out[i].ProductSetting = s
+		if s.Kind == SettingAction || s.Opcode == 0 {
+			continue
+		}
+		raw, ok := values[s.Opcode]
+		if !ok {
+			raw = c.querySetting(ctx, s.Opcode)
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			values[s.Opcode] = raw
+		}
+		out[i].Raw = raw
+	}
+	return out, nil
+}
+
+func (c *Client) querySetting(ctx context.Context, opcode byte) []byte {
+	ctx, cancel := context.WithTimeout(ctx, settingQueryTimeout)
+	defer cancel()
+	ev, err := c.Query(ctx, opcode)
+	if err != nil || ev.Raw == nil {
+		return nil
+	}
+	return ev.Raw
+}
+
+// lookupSetting finds a setting by name. Names of the form "0x24" address an
+// opcode directly as a toggle, so opcodes missing from the product database
+// can still be used.
+func (c *Client) lookupSetting(name string) (ProductSetting, error) {
+	if c.product != nil {
+		if s, ok := c.product.Setting(name); ok {
+			return s, nil
+		}
+	}
+	if hexOp, ok := strings.CutPrefix(strings.ToLower(name), "0x"); ok {
+		op, err := strconv.ParseUint(hexOp, 16, 8)
+		if err != nil || op == 0 {
+			return ProductSetting{}, fmt.Errorf("setting %q: invalid opcode", name)
+		}
+		id := int(op)
+		return product.SettingItem{Name: name, Type: product.SettingTypeName(200), CmdID: &id}.Normalize(), nil
+	}
+	return c.ProductSetting(name)
+}
+
+// SetSetting sets a product setting by name. value is "on" or "off" for
+// toggles, free-form text for text settings and a byte for sliders;
+// actions ignore it. Settings with a raw cmd in the control panel send that
+// frame as is. name may also be an opcode such as "0x24", which is sent as a
+// toggle.
+func (c *Client) SetSetting(ctx context.Context, name, value string) error {
+	s, err := c.lookupSetting(name)
+	if err != nil {
+		return err
+	}
+	cmds, err := encodeSetting(s, value)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range cmds {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.send(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSetting builds the commands that set s to value.
+func encodeSetting(s ProductSetting, value string) ([]*command.Command, error) {
+	if !s.Settable() {
+		return nil, fmt.Errorf("setting %q: no known command", s.Name)
+	}
+	if s.Kind == SettingAction {
+		parsed, err := command.ParsePacket(s.Frame)
+		if err != nil {
+			return nil, fmt.Errorf("setting %q: %w", s.Name, err)
+		}
+		cmds := make([]*command.Command, len(parsed))
+		for i := range parsed {
+			cmds[i] = &parsed[i]
+		}
+		return cmds, nil
+	}
+	arg, err := parseSettingValue(s, value)
+	if err != nil {
+		return nil, fmt.Errorf("setting %q: %w", s.Name, err)
+	}
+	return []*command.Command{command.NewCommand(s.Opcode, arg)}, nil
+}
+
+func parseSettingValue(s ProductSetting, value string) ([]byte, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	switch s.Kind {
+	case SettingToggle:
+		switch v {
+		case "on", "true", "1", "yes", "enable", "enabled":
+			return []byte{0x01}, nil
+		case "off", "false", "0", "2", "no", "disable", "disabled":
+			return []byte{0x02}, nil
+		}
+		return nil, fmt.Errorf("invalid toggle value %q, want on or off", value)
+	case SettingText:
+		return []byte(strings.TrimSpace(value)), nil
+	case SettingSlider:
+		n, err := strconv.ParseUint(v, 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q, want 0-255", value)
+		}
+		return []byte{byte(n)}, nil
+	}
+	return nil, fmt.Errorf("unsupported kind %s", s.Kind)
+}